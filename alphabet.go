@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+const paddingFile = "padding.txt"
+
+// parseCodePoint reads one hex code point, anything that isn't a valid rune,
+// like a surrogate or past U+10FFFF, is an error
+func parseCodePoint(s string) (rune, error) {
+	n, err := strconv.ParseInt(s, 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	if !utf8.ValidRune(rune(n)) {
+		return 0, fmt.Errorf("%s is not a valid rune", s)
+	}
+	return rune(n), nil
+}
+
+// readRuneFile reads a file with one hex code point per line, like
+// emojis.txt
+func readRuneFile(path string) ([]rune, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var runes []rune
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		r, err := parseCodePoint(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, lineNo, err)
+		}
+		runes = append(runes, r)
+	}
+	return runes, scanner.Err()
+}
+
+// readSymbolFile is readRuneFile for alphabets with sequences, each line is
+// one symbol of one or more hex code points separated by spaces, like
+//
+//	2615 fe0f
+func readSymbolFile(path string) ([][]rune, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var symbols [][]rune
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var sym []rune
+		for _, field := range fields {
+			r, err := parseCodePoint(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", path, lineNo, err)
+			}
+			sym = append(sym, r)
+		}
+		symbols = append(symbols, sym)
+	}
+	return symbols, scanner.Err()
+}
+
+// tableHex pulls the hex code points out of a markdown table cell like
+// "☕ (2615)" or "☀️ (2600 fe0f) (kept as a sequence)"
+var tableHex = regexp.MustCompile(`\(([0-9a-f]+(?: [0-9a-f]+)*)\)`)
+
+// readMarkdownAlphabet reads the alphabet back out of the tables
+// writeMarkdown makes, like suggested.md and result.md. A slot is its
+// replacement, or the v1 emoji when it's "-".
+func readMarkdownAlphabet(path string) (emojis, padding [][]rune, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var table *[][]rune
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "## Padding"):
+			table = &padding
+			continue
+		case strings.HasPrefix(line, "## Emojis"):
+			table = &emojis
+			continue
+		case strings.HasPrefix(line, "##"):
+			table = nil
+			continue
+		}
+		cells := strings.Split(line, "|")
+		if table == nil || len(cells) < 5 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(cells[1])); err != nil {
+			// the header, the line under it and unused rows
+			continue
+		}
+		cell := cells[3]
+		if strings.TrimSpace(cell) == "-" {
+			cell = cells[2]
+		}
+		match := tableHex.FindStringSubmatch(cell)
+		if match == nil {
+			return nil, nil, fmt.Errorf("%s line %d: no code points in %q", path, lineNo, strings.TrimSpace(cell))
+		}
+		var sym []rune
+		for _, field := range strings.Fields(match[1]) {
+			r, err := parseCodePoint(field)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s line %d: %w", path, lineNo, err)
+			}
+			sym = append(sym, r)
+		}
+		*table = append(*table, sym)
+	}
+	return emojis, padding, scanner.Err()
+}
+
+// symbolsOf makes every rune its own symbol
+func symbolsOf(runes []rune) [][]rune {
+	var symbols [][]rune
+	for _, r := range runes {
+		symbols = append(symbols, []rune{r})
+	}
+	return symbols
+}
+
+// symbolHex is a symbol's code points in hex, separated by spaces
+func symbolHex(sym []rune) string {
+	var parts []string
+	for _, r := range sym {
+		parts = append(parts, fmt.Sprintf("%x", r))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatSymbolFile(symbols [][]rune) []byte {
+	var b strings.Builder
+	for _, sym := range symbols {
+		fmt.Fprintln(&b, symbolHex(sym))
+	}
+	return []byte(b.String())
+}
+
+func formatRuneFile(runes []rune) []byte {
+	var b strings.Builder
+	for _, r := range runes {
+		fmt.Fprintf(&b, "%x\n", r)
+	}
+	return []byte(b.String())
+}
+
+func writeRuneFile(path string, runes []rune) error {
+	return os.WriteFile(path, formatRuneFile(runes), 0644)
+}
+
+// newSymbolAlphabet is newAlphabet for symbols
+func newSymbolAlphabet(emojis, padding [][]rune) (ecoji.SymbolAlphabet, error) {
+	var a ecoji.SymbolAlphabet
+	if len(emojis) != len(a.Emojis) {
+		return a, fmt.Errorf("alphabet needs %d emojis, got %d", len(a.Emojis), len(emojis))
+	}
+	if len(padding) != len(a.Padding) {
+		return a, fmt.Errorf("alphabet needs %d padding runes, got %d", len(a.Padding), len(padding))
+	}
+	for i, sym := range emojis {
+		a.Emojis[i] = string(sym)
+	}
+	for i, sym := range padding {
+		a.Padding[i] = string(sym)
+	}
+	return a, nil
+}
+
+// newAlphabet puts 1024 emojis and the 5 padding runes into an ecoji.Alphabet
+func newAlphabet(emojis, padding []rune) (ecoji.Alphabet, error) {
+	var a ecoji.Alphabet
+	if len(emojis) != len(a.Emojis) {
+		return a, fmt.Errorf("alphabet needs %d emojis, got %d", len(a.Emojis), len(emojis))
+	}
+	if len(padding) != len(a.Padding) {
+		return a, fmt.Errorf("alphabet needs %d padding runes, got %d", len(a.Padding), len(padding))
+	}
+	copy(a.Emojis[:], emojis)
+	copy(a.Padding[:], padding)
+	return a, nil
+}
+
+// planEncodings returns the v1 and v2 encodings described by a plan
+func planEncodings(p plan) (v1, v2 *ecoji.Encoding, err error) {
+	var originals []rune
+	for _, s := range p.Emojis {
+		originals = append(originals, s.Original)
+	}
+	v1Alphabet, err := newAlphabet(originals, paddingRunes)
+	if err != nil {
+		return nil, nil, err
+	}
+	v2Alphabet, err := newSymbolAlphabet(p.finalSymbols(), p.finalPaddingSymbols())
+	if err != nil {
+		return nil, nil, err
+	}
+	if v1, err = ecoji.NewEncoding(v1Alphabet); err != nil {
+		return nil, nil, err
+	}
+	if v2, err = ecoji.NewSymbolEncoding(v2Alphabet); err != nil {
+		return nil, nil, err
+	}
+	return v1, v2, nil
+}
+
+// loadEncoding loads an alphabet registered in alphabets.json, or one of
+// the alphabets we have files for, v1 is emojisv1.txt with the v1 padding
+// and v2 is emojis.txt and padding.txt, which can have sequences. Anything
+// else is the path to a generated emojis file, with its padding.txt next to
+// it, to the markdown tables or to a json spec.
+func loadEncoding(name string) (*ecoji.Encoding, error) {
+	var emojis, padding [][]rune
+	reg, err := readRegistry(registryFile)
+	if err != nil {
+		return nil, err
+	}
+	registered, isRegistered := reg.lookup(name)
+	switch {
+	case isRegistered:
+		emojis, padding, err = registered.symbols()
+	case name == "v1":
+		emojis, err = readSymbolFile("emojisv1.txt")
+		padding = symbolsOf(paddingRunes)
+	case name == "v2":
+		emojis, err = readSymbolFile("emojis.txt")
+		if err == nil {
+			padding, err = readSymbolFile(paddingFile)
+		}
+	default:
+		if strings.HasSuffix(name, ".md") {
+			emojis, padding, err = readMarkdownAlphabet(name)
+			break
+		}
+		if strings.HasSuffix(name, ".json") {
+			emojis, padding, err = readSpecAlphabet(name)
+			break
+		}
+		emojis, err = readSymbolFile(name)
+		if err == nil {
+			padding, err = readSymbolFile(filepath.Join(filepath.Dir(name), paddingFile))
+		}
+		if err != nil {
+			err = fmt.Errorf("alphabet %q isn't registered, v1, v2, a generated emojis file, markdown or a json spec: %w", name, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	a, err := newSymbolAlphabet(emojis, padding)
+	if err != nil {
+		return nil, err
+	}
+	return ecoji.NewSymbolEncoding(a)
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// withLength makes every generated input have a length that's k mod 5, so
+// each padding case gets hit
+func withLength(k int, f func([]byte) bool) func([]byte, [4]byte) bool {
+	return func(data []byte, tail [4]byte) bool {
+		return f(append(data[:len(data)/5*5], tail[:k]...))
+	}
+}
+
+func roundTrips(t *testing.T, name string, enc *ecoji.Encoding) {
+	for k := 0; k < 5; k++ {
+		f := withLength(k, func(data []byte) bool {
+			decoded, err := enc.Decode(enc.Encode(data))
+			return err == nil && bytes.Equal(decoded, data)
+		})
+		if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+			t.Fatalf("%s round trip with length %d mod 5: %v", name, k, err)
+		}
+	}
+}
+
+func TestRoundTripProperty(t *testing.T) {
+	for _, name := range []string{"v1", "v2"} {
+		enc, err := loadEncoding(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		roundTrips(t, name, enc)
+	}
+
+	ecojiset := testEcojiset(t)
+	for strategy := range selectors {
+		sel, err := newSelector(strategy, 1)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		p, err := buildPlan(ecojiset, builtinOverrides(), sel)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		_, v2, err := planEncodings(p)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		roundTrips(t, strategy, v2)
+	}
+}
+
+// orderPreserved is the fraction of random input pairs whose encodings sort
+// the same way the inputs do
+func orderPreserved(enc *ecoji.Encoding, rnd *rand.Rand, pairs int) float64 {
+	var kept int
+	for i := 0; i < pairs; i++ {
+		a, b := make([]byte, rnd.Intn(12)), make([]byte, rnd.Intn(12))
+		rnd.Read(a)
+		rnd.Read(b)
+		if cmp := bytes.Compare(a, b); cmp == compareStrings(enc.Encode(a), enc.Encode(b)) {
+			kept++
+		}
+	}
+	return float64(kept) / float64(pairs)
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func TestSortPreserving(t *testing.T) {
+	// v1 was designed to sort, so it always does
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	f := func(a, b []byte) bool {
+		return bytes.Compare(a, b) == compareStrings(v1.Encode(a), v1.Encode(b))
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatalf("v1 should preserve order: %v", err)
+	}
+
+	// v2 keeps every v1 emoji at its index, so even the sort strategy can't
+	// fit a candidate between every pair of neighbours. It should still keep
+	// the order far more often than taking candidates in sequence.
+	ecojiset := testEcojiset(t)
+	ov := builtinOverrides()
+	preserved := make(map[string]float64)
+	for _, strategy := range []string{"sequential", "sort"} {
+		sel, _ := newSelector(strategy, 1)
+		p, err := buildPlan(ecojiset, ov, sel)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		_, v2, err := planEncodings(p)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		preserved[strategy] = orderPreserved(v2, rand.New(rand.NewSource(1)), 20000)
+		if strategy == "sort" {
+			checkSelectedOrder(t, p, ov, v2)
+		}
+	}
+	if preserved["sort"] <= preserved["sequential"] {
+		t.Fatalf("sort strategy should preserve order more often, got %v", preserved)
+	}
+	t.Logf("order preserved: %v", preserved)
+}
+
+// checkSelectedOrder checks the order where the sort strategy is in control.
+// The slots it fills have to be ascending, and input made only of full
+// groups whose emojis all come from those slots has to encode in order.
+func checkSelectedOrder(t *testing.T, p plan, ov overrides, enc *ecoji.Encoding) {
+	padding := make(map[*slot]bool)
+	for i := range p.Padding {
+		padding[&p.Padding[i]] = true
+	}
+	var prev rune
+	var selected []int
+	for _, s := range sortedSlots(p) {
+		overridden := ov.Emojis
+		if padding[s] {
+			overridden = ov.Padding
+		}
+		if _, ok := overridden[s.Index]; ok || !s.replaced() {
+			continue
+		}
+		if s.Replacement <= prev {
+			t.Fatalf("selected %x sorts after %x", s.Replacement, prev)
+		}
+		prev = s.Replacement
+		if !padding[s] {
+			selected = append(selected, s.Index)
+		}
+	}
+	if len(selected) == 0 {
+		t.Fatalf("the sort strategy should fill some emoji slots")
+	}
+
+	// 4 emojis of 10 bits are one group of 5 bytes
+	rnd := rand.New(rand.NewSource(1))
+	var ids [][]byte
+	for i := 0; i < 2000; i++ {
+		id := make([]byte, 10)
+		for g := 0; g < len(id); g += 5 {
+			var bits uint64
+			for j := 0; j < 4; j++ {
+				bits = bits<<10 | uint64(selected[rnd.Intn(len(selected))])
+			}
+			for j := 4; j >= 0; j-- {
+				id[g+j] = byte(bits)
+				bits >>= 8
+			}
+		}
+		ids = append(ids, id)
+	}
+	if err := enc.CheckOrder(ids); err != nil {
+		t.Fatalf("input in the selected slots should keep its order: %v", err)
+	}
+}
+
+// splitSymbols splits encoded text back into its symbols, no symbol is a
+// prefix of another so the first one that matches is it
+func splitSymbols(enc *ecoji.Encoding, s string) []string {
+	a := enc.Symbols()
+	all := append(a.Emojis[:], a.Padding[:]...)
+	var symbols []string
+	for s != "" {
+		var matched bool
+		for _, sym := range all {
+			if strings.HasPrefix(s, sym) {
+				symbols = append(symbols, sym)
+				s = s[len(sym):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	return symbols
+}
+
+func isPadding(enc *ecoji.Encoding, sym string) bool {
+	for _, p := range enc.Symbols().Padding {
+		if p == sym {
+			return true
+		}
+	}
+	return false
+}
+
+var decodeSamples = []string{"", "a", "ab", "abc", "abcd", "abcde", "hello ecoji\n", "\x00\xff\x10\x80\x7f\x01"}
+
+// badEncodings breaks real encodings of the samples the ways we document,
+// dropping the last symbol truncates it and moving trailing padding to the
+// front puts it where it can't be
+func badEncodings(enc *ecoji.Encoding) map[string]error {
+	bad := map[string]error{"not ecoji": ecoji.ErrInvalidRune}
+	for _, sample := range decodeSamples {
+		symbols := splitSymbols(enc, enc.Encode([]byte(sample)))
+		if len(symbols) == 0 {
+			continue
+		}
+		last := symbols[len(symbols)-1]
+		bad[strings.Join(symbols[:len(symbols)-1], "")] = ecoji.ErrTruncated
+		if isPadding(enc, last) {
+			bad[last+strings.Join(symbols[:len(symbols)-1], "")] = ecoji.ErrPadding
+		}
+		bad[strings.Join(symbols, "")+"x"] = ecoji.ErrInvalidRune
+	}
+	return bad
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, name := range []string{"v1", "v2"} {
+		enc, err := loadEncoding(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		bad := badEncodings(enc)
+		if len(bad) < 2*len(decodeSamples) {
+			t.Fatalf("%s: only %d bad encodings", name, len(bad))
+		}
+		for s, want := range bad {
+			if _, err := enc.Decode(s); !errors.Is(err, want) {
+				t.Fatalf("%s: %q should fail with %v, got %v", name, s, want, err)
+			}
+		}
+	}
+}
+
+// seedEncodings adds some real encodings, broken ones and the alphabet files
+// themselves to a fuzz corpus
+func seedEncodings(f *testing.F, encodings ...*ecoji.Encoding) {
+	for _, enc := range encodings {
+		for _, sample := range decodeSamples {
+			f.Add(enc.Encode([]byte(sample)))
+		}
+		for s := range badEncodings(enc) {
+			f.Add(s)
+		}
+		a := enc.Alphabet()
+		f.Add(string(a.Emojis[:]))
+		f.Add(string(a.Padding[:]))
+	}
+	for _, path := range []string{"emojis.txt", "emojisv1.txt"} {
+		if runes, err := readRuneFile(path); err == nil {
+			f.Add(string(runes[:40]))
+		}
+	}
+}
+
+// checkDecode decodes s and makes sure that it either works and round trips,
+// or fails with one of the errors we document. A rune that's in no symbol
+// has to be ErrInvalidRune.
+func checkDecode(t *testing.T, enc *ecoji.Encoding, s string) ([]byte, bool) {
+	decoded, err := enc.Decode(s)
+	if err != nil {
+		if !errors.Is(err, ecoji.ErrInvalidRune) && !errors.Is(err, ecoji.ErrTruncated) && !errors.Is(err, ecoji.ErrPadding) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if r, ok := foreignRune(enc, s); ok && !errors.Is(err, ecoji.ErrInvalidRune) {
+			t.Fatalf("%q has %x which is in no symbol, expected %v, got %v", s, r, ecoji.ErrInvalidRune, err)
+		}
+		return nil, false
+	}
+	again, err := enc.Decode(enc.Encode(decoded))
+	if err != nil || !bytes.Equal(again, decoded) {
+		t.Fatalf("%q decoded to %x which doesn't round trip", s, decoded)
+	}
+	return decoded, true
+}
+
+// foreignRune finds a rune of s that isn't a line break or part of any
+// symbol of enc
+func foreignRune(enc *ecoji.Encoding, s string) (rune, bool) {
+	a := enc.Symbols()
+	known := make(map[rune]bool)
+	for _, sym := range append(a.Emojis[:], a.Padding[:]...) {
+		for _, r := range sym {
+			known[r] = true
+		}
+	}
+	for _, r := range s {
+		if r != '\n' && r != '\r' && !known[r] {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func FuzzDecode(f *testing.F) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		f.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		f.Fatalf("error %v", err)
+	}
+	lenientV1, err := v1.Lenient()
+	if err != nil {
+		f.Fatalf("error %v", err)
+	}
+	seedEncodings(f, v1, v2)
+	f.Add("\U0001F472\uFE0F\U0001F529\u200D\U0001F697\U0001F337")
+	f.Fuzz(func(t *testing.T, s string) {
+		checkDecode(t, v1, s)
+		checkDecode(t, v2, s)
+		// anything strict takes, lenient takes the same way
+		strict, strictErr := v1.Decode(s)
+		lenient, _, err := lenientV1.DecodeStripped(s)
+		if err != nil && !errors.Is(err, ecoji.ErrInvalidRune) && !errors.Is(err, ecoji.ErrTruncated) && !errors.Is(err, ecoji.ErrPadding) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if strictErr == nil && (err != nil || !bytes.Equal(strict, lenient)) {
+			t.Fatalf("%q decodes strictly to %x but leniently to %x %v", s, strict, lenient, err)
+		}
+	})
+}
+
+// TestLenientV1 pastes v1 text the way a chat app would, with U+FE0F after
+// every text default emoji
+func TestLenientV1(t *testing.T) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	lenient, err := v1.Lenient()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	data := make([]byte, 1280)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	var pasted strings.Builder
+	var added int
+	for _, r := range v1.Encode(data) {
+		pasted.WriteRune(r)
+		if !hasEmojiPresentation(r) && !ecoji.Ignorable(r) {
+			pasted.WriteRune(0xFE0F)
+			added++
+		}
+	}
+	if added == 0 {
+		t.Fatalf("v1 should have text default emojis")
+	}
+	if _, err := v1.Decode(pasted.String()); !errors.Is(err, ecoji.ErrInvalidRune) {
+		t.Fatalf("strict decoding should fail, got %v", err)
+	}
+	decoded, stripped, err := lenient.DecodeStripped(pasted.String())
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("lenient decoding failed: %v", err)
+	}
+	if len(stripped) != added {
+		t.Fatalf("expected %d stripped, got %d", added, len(stripped))
+	}
+}
+
+func FuzzTranscode(f *testing.F) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		f.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		f.Fatalf("error %v", err)
+	}
+	seedEncodings(f, v1)
+	f.Fuzz(func(t *testing.T, s string) {
+		decoded, ok := checkDecode(t, v1, s)
+		transcoded, err := ecoji.Transcode(v1, v2, s)
+		if (err == nil) != ok {
+			t.Fatalf("transcode and decode disagree on %q: %v", s, err)
+		}
+		if err != nil {
+			return
+		}
+		if got, _ := checkDecode(t, v2, transcoded); !bytes.Equal(got, decoded) {
+			t.Fatalf("%q transcoded to %q which decodes to %x instead of %x", s, transcoded, got, decoded)
+		}
+	})
+}
+
+func TestLoadEncodingPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"emojis.txt", paddingFile} {
+		buf, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), buf, 0644); err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	enc, err := loadEncoding(filepath.Join(dir, "emojis.txt"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if enc.Symbols() != v2.Symbols() {
+		t.Fatalf("loading emojis.txt by path should give v2")
+	}
+	if _, err := loadEncoding(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Fatalf("a missing file should be an error")
+	}
+}
+
+func TestReadInvalidRunes(t *testing.T) {
+	dir := t.TempDir()
+	for _, line := range []string{"d800", "110000", "7fffffff", "1f004 dfff"} {
+		path := filepath.Join(dir, "emojis.txt")
+		if err := os.WriteFile(path, []byte("1f004\n"+line+"\n"), 0644); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if _, err := readSymbolFile(path); err == nil {
+			t.Fatalf("%q isn't a valid symbol", line)
+		}
+		if _, err := readRuneFile(path); err == nil {
+			t.Fatalf("%q isn't a valid rune", line)
+		}
+	}
+}
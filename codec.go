@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// readInput reads every file in paths one after another, or stdin when
+// there are none
+func readInput(paths []string) ([]byte, error) {
+	if len(paths) == 0 {
+		return io.ReadAll(os.Stdin)
+	}
+	var buf bytes.Buffer
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// codecFlags are the flags encode, decode and transcode share
+type codecFlags struct {
+	flags   *flag.FlagSet
+	out     *string
+	lenient *bool
+}
+
+func newCodecFlags(name, usage string) codecFlags {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	c := codecFlags{
+		flags: flags,
+		out:   flags.String("o", "-", "output file, - for stdout"),
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ecojifixer %s [flags] [file...]\n", name)
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	return c
+}
+
+// mustLoadEncoding is loadEncoding for commands, a bad alphabet is bad usage
+func mustLoadEncoding(name string) *ecoji.Encoding {
+	enc, err := loadEncoding(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return enc
+}
+
+// withLenient adds -lenient, for the commands that decode
+func (c *codecFlags) withLenient() {
+	c.lenient = c.flags.Bool("lenient", false, "skip variation selectors, joiners and other ignorable code points, and say where")
+}
+
+// decoder is enc, made lenient when asked for
+func (c codecFlags) decoder(enc *ecoji.Encoding) *ecoji.Encoding {
+	if !*c.lenient {
+		return enc
+	}
+	lenient, err := enc.Lenient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return lenient
+}
+
+// strippedShown is how many stripped runes decode lists before it only
+// counts them, pasted text can have a variation selector after every emoji
+const strippedShown = 5
+
+// decode decodes with dec and reports anything it stripped on stderr,
+// garbage is only counted since there's usually a lot of it
+func (c codecFlags) decode(dec *ecoji.Encoding, s string) []byte {
+	decoded, stripped, err := dec.DecodeStripped(s)
+	var garbage int
+	var ignorable []string
+	for _, st := range stripped {
+		if !ecoji.Ignorable(st.Rune) {
+			garbage++
+			continue
+		}
+		ignorable = append(ignorable, fmt.Sprintf("%x at position %d", st.Rune, st.Pos))
+	}
+	if len(ignorable) > strippedShown {
+		fmt.Fprintf(os.Stderr, "stripped %d runes: %s and %d more\n", len(ignorable),
+			strings.Join(ignorable[:strippedShown], ", "), len(ignorable)-strippedShown)
+	} else if len(ignorable) > 0 {
+		fmt.Fprintf(os.Stderr, "stripped %d runes: %s\n", len(ignorable), strings.Join(ignorable, ", "))
+	}
+	if garbage > 0 {
+		fmt.Fprintf(os.Stderr, "ignored %d runes of garbage\n", garbage)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return decoded
+}
+
+func (c codecFlags) input() []byte {
+	buf, err := readInput(c.flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return buf
+}
+
+func (c codecFlags) write(buf []byte) {
+	if err := writeOutput(*c.out, buf); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runEncode(args []string) {
+	c := newCodecFlags("encode", "encodes the input, or stdin, as ecoji")
+	alphabet := c.flags.String("alphabet", "", "alphabet to encode with, a name from "+registryFile+", v1, v2 or the path to an emojis file, by default the newest final one that replaces v1")
+	wrap := c.flags.Int("wrap", 0, "break lines after this many emojis, 0 for one line")
+	draft := c.flags.Bool("draft", false, "allow encoding with an alphabet that isn't final in "+registryFile)
+	c.flags.Parse(args)
+
+	enc := mustLoadEncoder(*alphabet, *draft)
+	c.write([]byte(enc.EncodeWrapped(c.input(), *wrap) + "\n"))
+}
+
+func runDecode(args []string) {
+	c := newCodecFlags("decode", "decodes ecoji text from the input, or stdin")
+	alphabet := c.flags.String("alphabet", "", "alphabet to decode with, a name from "+registryFile+", v1, v2 or the path to an emojis file, by default the newest final one that replaces v1")
+	garbage := c.flags.Bool("ignore-garbage", false, "skip anything that isn't an emoji of the alphabet")
+	c.withLenient()
+	c.flags.Parse(args)
+
+	dec := c.decoder(mustLoadEncoding(mustResolveAlphabet(*alphabet)))
+	if *garbage {
+		dec = dec.IgnoreGarbage()
+	}
+	c.write(c.decode(dec, string(c.input())))
+}
+
+func runTranscode(args []string) {
+	c := newCodecFlags("transcode", "decodes ecoji text with one alphabet and encodes it with another")
+	from := c.flags.String("from", "v1", "alphabet the input is encoded with, a name from "+registryFile+", v1, v2 or the path to an emojis file")
+	to := c.flags.String("to", "", "alphabet to encode the output with, by default the newest final one in "+registryFile+" that replaces v1")
+	wrap := c.flags.Int("wrap", 0, "break lines after this many emojis, 0 for one line")
+	draft := c.flags.Bool("draft", false, "allow encoding with an alphabet that isn't final in "+registryFile)
+	c.withLenient()
+	c.flags.Parse(args)
+
+	if *to = mustResolveAlphabet(*to); *to == *from {
+		fmt.Fprintf(os.Stderr, "-from and -to are both %s, there's nothing to transcode\n", *to)
+		os.Exit(2)
+	}
+	dec, enc := c.decoder(mustLoadEncoding(*from)), mustLoadEncoder(*to, *draft)
+	c.write([]byte(enc.EncodeWrapped(c.decode(dec, string(c.input())), *wrap) + "\n"))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// words that only say what colour or size an emoji is, or what time a clock
+// shows, so two emojis that differ by them look alike
+var variantWords = map[string]bool{
+	"red": true, "orange": true, "yellow": true, "green": true, "blue": true,
+	"purple": true, "brown": true, "black": true, "white": true, "grey": true,
+	"gray": true, "pink": true, "light": true, "dark": true,
+	"large": true, "small": true, "medium": true, "big": true, "little": true,
+	"one": true, "two": true, "three": true, "four": true, "five": true, "six": true,
+	"seven": true, "eight": true, "nine": true, "ten": true, "eleven": true,
+	"twelve": true, "thirty": true,
+}
+
+// confusableKey is the subgroup and what's left of the name without colour,
+// size and time words, so "orange circle" and "purple circle" share a key
+// but "orange heart" doesn't. Symbols that aren't in emoji-test.txt have no
+// key.
+func confusableKey(sym []rune) (string, bool) {
+	info, ok := lookupSequence(sym)
+	if !ok && len(sym) == 1 {
+		info, ok = lookupEmoji(sym[0])
+	}
+	if !ok {
+		return "", false
+	}
+	words := keywords(info.Name)
+	// clock faces are "one o’clock" or "one-thirty", drop the hour and both
+	// are just a clock
+	if words["thirty"] || words["o"] && words["clock"] {
+		return info.Subgroup + "/clock", true
+	}
+	var base []string
+	for w := range words {
+		if !variantWords[w] {
+			base = append(base, w)
+		}
+	}
+	sort.Strings(base)
+	return info.Subgroup + "/" + strings.Join(base, " "), true
+}
+
+// confusableClusters groups symbols by confusableKey, keeping only groups of
+// more than one. Clusters are in the order their first member appears.
+func confusableClusters(symbols [][]rune) [][][]rune {
+	byKey := make(map[string]int)
+	var clusters [][][]rune
+	for _, sym := range symbols {
+		key, ok := confusableKey(sym)
+		if !ok {
+			continue
+		}
+		i, seen := byKey[key]
+		if !seen {
+			i = len(clusters)
+			byKey[key] = i
+			clusters = append(clusters, nil)
+		}
+		clusters[i] = append(clusters[i], sym)
+	}
+	var out [][][]rune
+	for _, c := range clusters {
+		if len(c) > 1 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// confusableCluster is a group of look alike emojis and the one we keep.
+// Kept is already in the alphabet when InAlphabet is set, otherwise it's
+// the first candidate of the cluster no other exclusion takes out.
+// ExcludedBy names the exclusion that took out Kept anyway when every
+// candidate was.
+type confusableCluster struct {
+	Members    []rune
+	Kept       rune
+	InAlphabet bool
+	ExcludedBy string
+}
+
+// confusableExclusion clusters every candidate, before any exclusions, with
+// the emojis the alphabet keeps anyway, and excludes all but one member of
+// each cluster. It has the lowest priority, so it's the first let back in.
+func confusableExclusion(ecojiset []rune, ov overrides, excl []exclusion) (exclusion, []confusableCluster) {
+	pool, _ := candidatePool(ecojiset, ov, nil)
+	inPool := make(map[rune]bool)
+	for _, r := range pool {
+		inPool[r] = true
+	}
+	excluded := make(map[rune]string)
+	for _, e := range excl {
+		for _, emoji := range e.Runes {
+			excluded[emoji[0]] = e.Name
+		}
+	}
+
+	var symbols [][]rune
+	for _, runes := range [][]rune{paddingRunes, ecojiset} {
+		for _, r := range runes {
+			if checkRune(r) {
+				symbols = append(symbols, []rune{r})
+			}
+		}
+	}
+	for _, overridden := range []map[int]rune{ov.Padding, ov.Emojis} {
+		var indexes []int
+		for i := range overridden {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			symbols = append(symbols, []rune{overridden[i]})
+		}
+	}
+	for _, r := range pool {
+		symbols = append(symbols, []rune{r})
+	}
+
+	e := exclusion{Name: "confusable", Priority: 0}
+	var clusters []confusableCluster
+	for _, c := range confusableClusters(symbols) {
+		cluster := confusableCluster{Kept: c[0][0], InAlphabet: !inPool[c[0][0]]}
+		if !cluster.InAlphabet {
+			cluster.ExcludedBy = excluded[cluster.Kept]
+			for _, sym := range c {
+				if excluded[sym[0]] == "" {
+					cluster.Kept = sym[0]
+					cluster.ExcludedBy = ""
+					break
+				}
+			}
+		}
+		var candidates int
+		for _, sym := range c {
+			cluster.Members = append(cluster.Members, sym[0])
+			if sym[0] != cluster.Kept && inPool[sym[0]] {
+				e.Runes = append(e.Runes, sym)
+				candidates++
+			}
+		}
+		// a cluster that's all alphabet is v1's problem, there's nothing to
+		// exclude
+		if candidates > 0 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return e, clusters
+}
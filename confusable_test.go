@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestConfusableKey(t *testing.T) {
+	key := func(r rune) string {
+		k, ok := confusableKey([]rune{r})
+		if !ok {
+			t.Fatalf("%x should have a key", r)
+		}
+		return k
+	}
+	// orange and purple circle, orange heart
+	if key(0x1F7E0) != key(0x1F7E3) {
+		t.Fatalf("coloured circles should share a key, got %q and %q", key(0x1F7E0), key(0x1F7E3))
+	}
+	if key(0x1F7E0) == key(0x1F9E1) {
+		t.Fatalf("a circle and a heart shouldn't share a key")
+	}
+	// every clock face, on the hour and half past
+	for r := rune(0x1F550); r <= 0x1F567; r++ {
+		if key(r) != key(0x1F550) {
+			t.Fatalf("clock faces should share a key, got %q for %x and %q for 1f550", key(r), r, key(0x1F550))
+		}
+	}
+	if key(0x1F550) == key(0x23F0) {
+		t.Fatalf("an alarm clock isn't a clock face")
+	}
+	clusters := confusableClusters([][]rune{{0x1F7E0}, {0x1F400}, {0x1F9E1}, {0x1F7E3}, {0x1F49C}})
+	if len(clusters) != 2 || len(clusters[0]) != 2 || clusters[0][1][0] != 0x1F7E3 {
+		t.Fatalf("expected the circles then the hearts, got %v", clusters)
+	}
+}
+
+func TestConfusableExclusion(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	ov := builtinOverrides()
+
+	// without the hand written redundant list the coloured squares are all
+	// candidates, so one stays and the rest go
+	var withoutRedundant []exclusion
+	for _, e := range exclusions {
+		if e.Name != "redundant" {
+			withoutRedundant = append(withoutRedundant, e)
+		}
+	}
+	confusable, clusters := confusableExclusion(ecojiset, ov, withoutRedundant)
+	excluded := make(map[rune]bool)
+	for _, emoji := range confusable.Runes {
+		excluded[emoji[0]] = true
+	}
+	var squares *confusableCluster
+	for i, c := range clusters {
+		for _, r := range c.Members {
+			if r == 0x1F7E7 {
+				squares = &clusters[i]
+			}
+		}
+	}
+	if squares == nil || squares.InAlphabet || squares.ExcludedBy != "" || excluded[squares.Kept] {
+		t.Fatalf("expected one of the coloured squares kept, got %+v", squares)
+	}
+	for _, r := range squares.Members {
+		if r != squares.Kept && !excluded[r] {
+			t.Fatalf("%x should be excluded along with the rest of its cluster", r)
+		}
+	}
+
+	// the hearts cluster around the ones v1 has, so every candidate heart goes
+	for _, c := range clusters {
+		if c.Kept == 0x1F499 && !c.InAlphabet {
+			t.Fatalf("the blue heart is in v1")
+		}
+	}
+	if !excluded[0x1F9E1] {
+		t.Fatalf("the orange heart should be excluded")
+	}
+
+	opts := defaultPlanOptions()
+	opts.Exclusions = append(withoutRedundant, confusable)
+	p, err := relaxPlan(ecojiset, ov, sequentialSelector{}, opts)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	relaxed := make(map[rune]bool)
+	for _, r := range p.Relaxed {
+		relaxed[r.Rune] = true
+	}
+	for _, r := range p.finalSet() {
+		if excluded[r] && !relaxed[r] {
+			t.Fatalf("%x is confusable but ended up in the alphabet", r)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// slotChange is one slot that holds a different rune in two alphabets
+type slotChange struct {
+	Kind  string
+	Index int
+	From  []rune
+	To    []rune
+}
+
+func diffAlphabets(from, to ecoji.SymbolAlphabet) []slotChange {
+	var changes []slotChange
+	for i := range from.Padding {
+		if from.Padding[i] != to.Padding[i] {
+			changes = append(changes, slotChange{Kind: "padding", Index: i, From: []rune(from.Padding[i]), To: []rune(to.Padding[i])})
+		}
+	}
+	for i := range from.Emojis {
+		if from.Emojis[i] != to.Emojis[i] {
+			changes = append(changes, slotChange{Kind: "emoji", Index: i, From: []rune(from.Emojis[i]), To: []rune(to.Emojis[i])})
+		}
+	}
+	return changes
+}
+
+func writeDiff(w io.Writer, format string, changes []slotChange) error {
+	switch format {
+	case "text":
+		for _, c := range changes {
+			fmt.Fprintf(w, "%s %d: %s (%s) -> %s (%s)\n", c.Kind, c.Index, string(c.From), symbolHex(c.From), string(c.To), symbolHex(c.To))
+		}
+		fmt.Fprintf(w, "%d slots differ\n", len(changes))
+	case "markdown":
+		fmt.Fprintf(w, "| kind | index | from (hex) | to (hex) |\n")
+		fmt.Fprintf(w, "|------|-------|------------|----------|\n")
+		for _, c := range changes {
+			fmt.Fprintf(w, "| %s | %d | %s (%s) | %s (%s) |\n", c.Kind, c.Index, string(c.From), symbolHex(c.From), string(c.To), symbolHex(c.To))
+		}
+	case "json":
+		// runes as hex, like everywhere else
+		type jsonChange struct {
+			Kind  string `json:"kind"`
+			Index int    `json:"index"`
+			From  string `json:"from"`
+			To    string `json:"to"`
+		}
+		out := []jsonChange{}
+		for _, c := range changes {
+			out = append(out, jsonChange{c.Kind, c.Index, symbolHex(c.From), symbolHex(c.To)})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	default:
+		return fmt.Errorf("unknown format %q, use text, markdown or json", format)
+	}
+	return nil
+}
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "text, markdown or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer diff [flags] [from] [to]")
+		fmt.Fprintln(flags.Output(), "compares two alphabets slot by slot, v1 and v2 by default. like diff(1) it exits 1 when they differ")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	names := []string{"v1", "v2"}
+	if flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	copy(names, flags.Args())
+	var alphabets []ecoji.SymbolAlphabet
+	for _, name := range names {
+		alphabets = append(alphabets, mustLoadEncoding(name).Symbols())
+	}
+
+	changes := diffAlphabets(alphabets[0], alphabets[1])
+	if err := writeDiff(os.Stdout, *format, changes); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffAlphabets(t *testing.T) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if changes := diffAlphabets(v1.Symbols(), v1.Symbols()); len(changes) != 0 {
+		t.Fatalf("an alphabet shouldn't differ from itself, got %v", changes)
+	}
+
+	changes := diffAlphabets(v1.Symbols(), v2.Symbols())
+	p, err := loadPlan(defaultMappingFile, "", sequentialSelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	st := p.stats()
+	if len(changes) != st.EmojisReplaced+st.PaddingReplaced {
+		t.Fatalf("expected a change per replacement, got %d", len(changes))
+	}
+	if c := changes[0]; c.Kind != "padding" || c.Index != 1 || string(c.From) != "\u269C" || string(c.To) != "\U0001FAB4" {
+		t.Fatalf("unexpected first change %+v", c)
+	}
+
+	for _, format := range []string{"text", "markdown", "json"} {
+		var b strings.Builder
+		if err := writeDiff(&b, format, changes); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if !strings.Contains(b.String(), "1fab4") {
+			t.Fatalf("%s diff should mention 1fab4, got %s", format, b.String())
+		}
+	}
+	if err := writeDiff(&strings.Builder{}, "yaml", changes); err == nil {
+		t.Fatalf("unknown formats should be an error")
+	}
+}
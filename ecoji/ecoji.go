@@ -0,0 +1,454 @@
+// Package ecoji encodes and decodes data using an Ecoji alphabet, 1024
+// emojis carrying 10 bits each plus 5 padding runes. Alphabets can also use
+// fixed sequences of code points as symbols, see SymbolAlphabet.
+package ecoji
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Alphabet is a complete Ecoji alphabet. Padding is in the same order as the
+// constants in the reference implementation: padding, padding40, padding41,
+// padding42 and padding43.
+type Alphabet struct {
+	Emojis  [1024]rune
+	Padding [5]rune
+}
+
+const (
+	padding = iota
+	padding40
+	padding41
+	padding42
+	padding43
+)
+
+var (
+	// ErrInvalidRune is returned when the input has a rune that isn't in the alphabet
+	ErrInvalidRune = errors.New("ecoji: invalid rune")
+	// ErrTruncated is returned when the input isn't a multiple of 4 runes
+	ErrTruncated = errors.New("ecoji: truncated input")
+	// ErrPadding is returned when padding shows up where it can't
+	ErrPadding = errors.New("ecoji: unexpected padding")
+)
+
+// SymbolAlphabet is an Alphabet where each symbol can be a fixed sequence of
+// code points, like an emoji and U+FE0F. No symbol can be a prefix of
+// another, so a decoder always knows where one ends.
+type SymbolAlphabet struct {
+	Emojis  [1024]string
+	Padding [5]string
+}
+
+// Symbols turns every rune of a into a one code point symbol
+func (a Alphabet) Symbols() SymbolAlphabet {
+	var sa SymbolAlphabet
+	for i, r := range a.Emojis {
+		sa.Emojis[i] = string(r)
+	}
+	for i, r := range a.Padding {
+		sa.Padding[i] = string(r)
+	}
+	return sa
+}
+
+// Encoding is an alphabet ready to encode and decode with
+type Encoding struct {
+	symbols SymbolAlphabet
+	// single code point symbols are looked up by rune, longer ones by string
+	rev    map[rune]int
+	seqRev map[string]int
+	maxLen int
+	// lenient decodes with the ignorable code points stripped, nil when
+	// decoding strictly
+	lenient *Encoding
+	// ignoreGarbage skips anything that isn't a symbol when decoding
+	ignoreGarbage bool
+}
+
+// Stripped is a code point a lenient decode skipped, or garbage when
+// ignoring garbage. Pos counts runes from the start of the input.
+type Stripped struct {
+	Pos  int
+	Rune rune
+}
+
+// NewEncoding checks that every rune in the alphabet is distinct and builds
+// the reverse lookup
+func NewEncoding(a Alphabet) (*Encoding, error) {
+	return NewSymbolEncoding(a.Symbols())
+}
+
+// NewSymbolEncoding checks that every symbol is distinct and not a prefix of
+// another, and builds the reverse lookup
+func NewSymbolEncoding(a SymbolAlphabet) (*Encoding, error) {
+	e := &Encoding{symbols: a, rev: make(map[rune]int), seqRev: make(map[string]int), maxLen: 1}
+	all := make([]string, 0, len(a.Emojis)+len(a.Padding))
+	add := func(kind string, i int, sym string, v int) error {
+		runes := []rune(sym)
+		switch {
+		case len(runes) == 0:
+			return fmt.Errorf("ecoji: %s at index %d is empty", kind, i)
+		case strings.ContainsAny(sym, "\r\n"):
+			return fmt.Errorf("ecoji: %s at index %d has a line break", kind, i)
+		}
+		if _, dup := e.rev[runes[0]]; dup && len(runes) == 1 {
+			return fmt.Errorf("ecoji: %s %x at index %d is a duplicate", kind, runes[0], i)
+		}
+		if _, dup := e.seqRev[sym]; dup {
+			return fmt.Errorf("ecoji: %s %x at index %d is a duplicate", kind, runes, i)
+		}
+		if len(runes) == 1 {
+			e.rev[runes[0]] = v
+		} else {
+			e.seqRev[sym] = v
+		}
+		if len(runes) > e.maxLen {
+			e.maxLen = len(runes)
+		}
+		all = append(all, sym)
+		return nil
+	}
+	for i, sym := range a.Emojis {
+		if err := add("emoji", i, sym, i); err != nil {
+			return nil, err
+		}
+	}
+	for i, sym := range a.Padding {
+		if err := add("padding", i, sym, -1-i); err != nil {
+			return nil, err
+		}
+	}
+	// after sorting, a symbol that's a prefix of others sorts right before
+	// the first of them
+	sort.Strings(all)
+	for i := 1; i < len(all); i++ {
+		if strings.HasPrefix(all[i], all[i-1]) {
+			return nil, fmt.Errorf("ecoji: %x is a prefix of %x", []rune(all[i-1]), []rune(all[i]))
+		}
+	}
+	return e, nil
+}
+
+// Alphabet returns the first code point of every symbol, which is the whole
+// alphabet when e was built with NewEncoding
+func (e *Encoding) Alphabet() Alphabet {
+	var a Alphabet
+	for i, sym := range e.symbols.Emojis {
+		a.Emojis[i] = []rune(sym)[0]
+	}
+	for i, sym := range e.symbols.Padding {
+		a.Padding[i] = []rune(sym)[0]
+	}
+	return a
+}
+
+// Symbols returns the symbols e was built with
+func (e *Encoding) Symbols() SymbolAlphabet {
+	return e.symbols
+}
+
+// Encode encodes every 5 bytes of src as 4 emojis
+func (e *Encoding) Encode(src []byte) string {
+	return e.EncodeWrapped(src, 0)
+}
+
+// EncodeWrapped is Encode with a line break after every width symbols, like
+// base64 -w. Decode skips line breaks. A width of 0 doesn't wrap.
+func (e *Encoding) EncodeWrapped(src []byte, width int) string {
+	var b strings.Builder
+	var n int
+	write := func(sym string) {
+		if width > 0 && n > 0 && n%width == 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(sym)
+		n++
+	}
+	for len(src) > 0 {
+		size := len(src)
+		if size > 5 {
+			size = 5
+		}
+		e.encodeGroup(write, src[:size])
+		src = src[size:]
+	}
+	return b.String()
+}
+
+func (e *Encoding) encodeGroup(write func(string), s []byte) {
+	emojis := &e.symbols.Emojis
+	pad := &e.symbols.Padding
+
+	var buf [5]int
+	for i, c := range s {
+		buf[i] = int(c)
+	}
+	b0, b1, b2, b3, b4 := buf[0], buf[1], buf[2], buf[3], buf[4]
+
+	write(emojis[b0<<2|b1>>6])
+	switch len(s) {
+	case 1:
+		write(pad[padding])
+		write(pad[padding])
+		write(pad[padding])
+	case 2:
+		write(emojis[(b1&0x3f)<<4|b2>>4])
+		write(pad[padding])
+		write(pad[padding])
+	case 3:
+		write(emojis[(b1&0x3f)<<4|b2>>4])
+		write(emojis[(b2&0x0f)<<6|b3>>2])
+		write(pad[padding])
+	case 4:
+		write(emojis[(b1&0x3f)<<4|b2>>4])
+		write(emojis[(b2&0x0f)<<6|b3>>2])
+		write(pad[padding40+b3&0x03])
+	case 5:
+		write(emojis[(b1&0x3f)<<4|b2>>4])
+		write(emojis[(b2&0x0f)<<6|b3>>2])
+		write(emojis[(b3&0x03)<<8|b4])
+	}
+}
+
+// next finds the symbol at the start of runes, the longest one wins. It
+// returns the symbol's value and how many runes it took.
+func (e *Encoding) next(runes []rune) (int, int, bool) {
+	for n := e.maxLen; n > 1; n-- {
+		if n > len(runes) {
+			continue
+		}
+		if v, ok := e.seqRev[string(runes[:n])]; ok {
+			return v, n, true
+		}
+	}
+	v, ok := e.rev[runes[0]]
+	return v, 1, ok
+}
+
+// Ignorable reports whether r is one of the code points chat apps and
+// editors add to or drop from emojis: variation selectors, zero width
+// joiners and spaces, skin tone modifiers and tags.
+func Ignorable(r rune) bool {
+	switch {
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		return true
+	case r >= 0x200B && r <= 0x200D, r == 0x2060, r == 0xFEFF:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		return true
+	}
+	return false
+}
+
+// ignores reports whether a lenient e skips r, which is any ignorable code
+// point that isn't a symbol on its own. v1 has the skin tones as emojis.
+func (e *Encoding) ignores(r rune) bool {
+	_, symbol := e.rev[r]
+	return Ignorable(r) && !symbol
+}
+
+// Lenient returns a copy of e that decodes text with ignorable code points
+// added or removed, like U+FE0F after a text default emoji. Symbols are
+// matched with their ignorable code points stripped too, so it's an error
+// if that makes two of them ambiguous. Encoding is unchanged.
+func (e *Encoding) Lenient() (*Encoding, error) {
+	strip := func(sym string) string {
+		return strings.Map(func(r rune) rune {
+			if e.ignores(r) {
+				return -1
+			}
+			return r
+		}, sym)
+	}
+	var stripped SymbolAlphabet
+	for i, sym := range e.symbols.Emojis {
+		stripped.Emojis[i] = strip(sym)
+	}
+	for i, sym := range e.symbols.Padding {
+		stripped.Padding[i] = strip(sym)
+	}
+	dec, err := NewSymbolEncoding(stripped)
+	if err != nil {
+		return nil, fmt.Errorf("ecoji: alphabet is ambiguous without ignorable code points: %w", err)
+	}
+	l := *e
+	l.lenient = dec
+	return &l, nil
+}
+
+// IgnoreGarbage returns a copy of e that skips anything that isn't part of
+// a symbol when decoding, like base64 --ignore-garbage, instead of failing
+// with ErrInvalidRune
+func (e *Encoding) IgnoreGarbage() *Encoding {
+	g := *e
+	g.ignoreGarbage = true
+	return &g
+}
+
+// Decode decodes s, line breaks are ignored. Positions in errors count
+// runes.
+func (e *Encoding) Decode(s string) ([]byte, error) {
+	out, _, err := e.DecodeStripped(s)
+	return out, err
+}
+
+// DecodeStripped is Decode that also returns every ignorable code point a
+// lenient encoding skipped, and the garbage it ignored. A strict encoding
+// never strips anything, it fails with ErrInvalidRune instead.
+func (e *Encoding) DecodeStripped(s string) ([]byte, []Stripped, error) {
+	tokens, stripped, err := e.tokens([]rune(s))
+	if err != nil {
+		return nil, stripped, err
+	}
+	out, err := decodeTokens(tokens)
+	return out, stripped, err
+}
+
+// token is one symbol's value and the position it started at
+type token struct {
+	v   int
+	pos int
+}
+
+// tokens splits runes into symbols. Symbols are matched exactly first, and
+// when lenient, then with ignorable code points skipped.
+func (e *Encoding) tokens(runes []rune) ([]token, []Stripped, error) {
+	var tokens []token
+	var stripped []Stripped
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		if r == '\n' || r == '\r' {
+			pos++
+			continue
+		}
+		if v, size, ok := e.next(runes[pos:]); ok {
+			tokens = append(tokens, token{v, pos})
+			pos += size
+			continue
+		}
+		if e.lenient == nil && e.ignoreGarbage {
+			stripped = append(stripped, Stripped{Pos: pos, Rune: r})
+			pos++
+			continue
+		}
+		if e.lenient == nil {
+			if Ignorable(r) {
+				return nil, nil, fmt.Errorf("%w: ignorable %x at position %d, decode leniently to skip it", ErrInvalidRune, r, pos)
+			}
+			return nil, nil, fmt.Errorf("%w: %x at position %d", ErrInvalidRune, r, pos)
+		}
+		if e.ignores(r) {
+			stripped = append(stripped, Stripped{Pos: pos, Rune: r})
+			pos++
+			continue
+		}
+
+		// try again without the ignorable code points, and strip the ones
+		// inside whatever symbol matches
+		var kept []rune
+		var at []int
+		for i := pos; i < len(runes) && len(kept) < e.lenient.maxLen; i++ {
+			if runes[i] == '\n' || runes[i] == '\r' {
+				break
+			}
+			if !e.ignores(runes[i]) {
+				kept = append(kept, runes[i])
+				at = append(at, i)
+			}
+		}
+		v, size, ok := e.lenient.next(kept)
+		if !ok && e.ignoreGarbage {
+			stripped = append(stripped, Stripped{Pos: pos, Rune: r})
+			pos++
+			continue
+		}
+		if !ok {
+			return nil, stripped, fmt.Errorf("%w: %x at position %d", ErrInvalidRune, r, pos)
+		}
+		end := at[size-1] + 1
+		for i := pos; i < end; i++ {
+			if e.ignores(runes[i]) {
+				stripped = append(stripped, Stripped{Pos: i, Rune: runes[i]})
+			}
+		}
+		tokens = append(tokens, token{v, pos})
+		pos = end
+	}
+	return tokens, stripped, nil
+}
+
+// decodeTokens decodes every 4 tokens into up to 5 bytes
+func decodeTokens(tokens []token) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(tokens); i += 4 {
+		if i > 0 && len(out) < i/4*5 {
+			return nil, fmt.Errorf("%w: data after padding at position %d", ErrPadding, tokens[i].pos)
+		}
+		if len(tokens)-i < 4 {
+			return nil, fmt.Errorf("%w: %d symbols left over", ErrTruncated, len(tokens)-i)
+		}
+		group := [4]int{tokens[i].v, tokens[i+1].v, tokens[i+2].v, tokens[i+3].v}
+		decoded, err := decodeGroup(group)
+		if err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, tokens[i].pos)
+		}
+		out = append(out, decoded...)
+	}
+	return out, nil
+}
+
+// decodeGroup decodes 4 symbol values, emojis are 0-1023 and padding p is
+// -1-p
+func decodeGroup(group [4]int) ([]byte, error) {
+	var bits [4]int
+	length := 5
+	for i, v := range group {
+		if v >= 0 {
+			if length < 5 {
+				return nil, fmt.Errorf("%w: emoji after padding", ErrPadding)
+			}
+			bits[i] = v
+			continue
+		}
+		p := -1 - v
+		switch {
+		case i == 0:
+			return nil, fmt.Errorf("%w: group starts with padding", ErrPadding)
+		case p == padding:
+			if length == 5 {
+				length = i
+			}
+		case i == 3 && length == 5:
+			// padding40 through padding43 carry the last 2 bits of the 4th byte
+			bits[i] = (p - padding40) << 8
+			length = 4
+		default:
+			return nil, fmt.Errorf("%w: padding4x in position %d", ErrPadding, i)
+		}
+	}
+
+	out := []byte{
+		byte(bits[0] >> 2),
+		byte((bits[0]&0x3)<<6 | bits[1]>>4),
+		byte((bits[1]&0xf)<<4 | bits[2]>>6),
+		byte((bits[2]&0x3f)<<2 | bits[3]>>8),
+		byte(bits[3] & 0xff),
+	}
+	return out[:length], nil
+}
+
+// Transcode decodes s with from and encodes the result with to, so text
+// encoded with one alphabet can be moved to another
+func Transcode(from, to *Encoding, s string) (string, error) {
+	decoded, err := from.Decode(s)
+	if err != nil {
+		return "", err
+	}
+	return to.Encode(decoded), nil
+}
@@ -0,0 +1,206 @@
+package ecoji
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testAlphabet() Alphabet {
+	var a Alphabet
+	for i := range a.Emojis {
+		a.Emojis[i] = rune(0x1F400 + i)
+	}
+	a.Padding = [5]rune{0x2615, 0x269C, 0x1F3F0, 0x1F3F1, 0x1F3F2}
+	return a
+}
+
+func TestRoundTrip(t *testing.T) {
+	enc, err := NewEncoding(testAlphabet())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	input := []byte("the quick brown fox jumps over the lazy dog")
+	for i := 0; i <= len(input); i++ {
+		encoded := enc.Encode(input[:i])
+		if got, want := len([]rune(encoded)), (i+4)/5*4; got != want {
+			t.Fatalf("encoding %d bytes should be %d runes, got %d", i, want, got)
+		}
+		decoded, err := enc.Decode(encoded)
+		if err != nil {
+			t.Fatalf("error decoding %d bytes: %v", i, err)
+		}
+		if !bytes.Equal(decoded, input[:i]) {
+			t.Fatalf("round trip of %q gave %q", input[:i], decoded)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	enc, err := NewEncoding(testAlphabet())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for _, tc := range []struct {
+		input string
+		err   error
+	}{
+		{"abcd", ErrInvalidRune},
+		{string([]rune{0x1F400, 0x1F401}), ErrTruncated},
+		{string([]rune{0x2615, 0x1F400, 0x1F400, 0x1F400}), ErrPadding},
+		{string([]rune{0x1F400, 0x2615, 0x1F400, 0x1F400}), ErrPadding},
+		{string([]rune{0x1F400, 0x269C, 0x1F400, 0x1F400}), ErrPadding},
+		{string([]rune{0x1F400, 0x2615, 0x2615, 0x2615, 0x1F400, 0x1F400, 0x1F400, 0x1F400}), ErrPadding},
+	} {
+		if _, err := enc.Decode(tc.input); !errors.Is(err, tc.err) {
+			t.Fatalf("decoding %q should fail with %v, got %v", tc.input, tc.err, err)
+		}
+	}
+}
+
+func TestSymbolAlphabet(t *testing.T) {
+	a := testAlphabet().Symbols()
+	// a few text by default emojis kept with their variation selector, and
+	// a keycap
+	a.Emojis[1] = "☀️"
+	a.Emojis[2] = "☁️"
+	a.Emojis[3] = "#️⃣"
+	a.Padding[0] = "☕️"
+	enc, err := NewSymbolEncoding(a)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	input := []byte{0x00, 0x40, 0x20, 0x30, 0xff, 0x00, 0x40}
+	encoded := enc.Encode(input)
+	if !strings.Contains(encoded, a.Emojis[1]) || !strings.Contains(encoded, a.Padding[0]) {
+		t.Fatalf("expected the sequences in %q", encoded)
+	}
+	runes := []rune(encoded)
+	decoded, err := enc.Decode(string(runes[:4]) + "\n" + string(runes[4:]))
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Fatalf("round trip gave %x %v", decoded, err)
+	}
+	if got := enc.Alphabet().Emojis[3]; got != '#' {
+		t.Fatalf("Alphabet should have the first code point, got %x", got)
+	}
+
+	// a bare 2600 isn't a symbol, and neither is half a keycap
+	for _, input := range []string{"\U0001F400☀\U0001F400\U0001F400", "\U0001F400#️\U0001F400\U0001F400"} {
+		if _, err := enc.Decode(input); !errors.Is(err, ErrInvalidRune) {
+			t.Fatalf("decoding %q should fail with %v, got %v", input, ErrInvalidRune, err)
+		}
+	}
+
+	prefix := a
+	prefix.Emojis[4] = "#️"
+	if _, err := NewSymbolEncoding(prefix); err == nil {
+		t.Fatalf("a symbol that's a prefix of another should be an error")
+	}
+	dup := a
+	dup.Emojis[4] = dup.Emojis[3]
+	if _, err := NewSymbolEncoding(dup); err == nil {
+		t.Fatalf("duplicate sequences should be an error")
+	}
+}
+
+func TestLenient(t *testing.T) {
+	a := testAlphabet().Symbols()
+	a.Emojis[1] = "☀️"
+	// like v1, where the skin tones are emojis on their own
+	a.Emojis[2] = "\U0001F3FB"
+	strict, err := NewSymbolEncoding(a)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	lenient, err := strict.Lenient()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	// 🐀 ☀️ ☀️ 🏻, pasted with a VS16 after the rat, the first sun's VS16
+	// dropped and a ZWJ before the skin tone
+	input := []byte{0x00, 0x00, 0x10, 0x04, 0x02}
+	encoded := strict.Encode(input)
+	if encoded != "\U0001F400☀️☀️\U0001F3FB" {
+		t.Fatalf("unexpected encoding %q", encoded)
+	}
+	pasted := "\U0001F400\uFE0F☀☀️\u200D\U0001F3FB"
+
+	if _, err := strict.Decode(pasted); !errors.Is(err, ErrInvalidRune) {
+		t.Fatalf("strict decoding should fail with %v, got %v", ErrInvalidRune, err)
+	}
+	decoded, stripped, err := lenient.DecodeStripped(pasted)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Fatalf("lenient round trip gave %x %v", decoded, err)
+	}
+	want := []Stripped{{Pos: 1, Rune: 0xFE0F}, {Pos: 5, Rune: 0x200D}}
+	if len(stripped) != len(want) || stripped[0] != want[0] || stripped[1] != want[1] {
+		t.Fatalf("expected %v stripped, got %v", want, stripped)
+	}
+	if got := lenient.Encode(input); got != encoded {
+		t.Fatalf("lenient shouldn't change encoding, got %q", got)
+	}
+
+	// errors still point into the original text
+	_, _, err = lenient.DecodeStripped(string([]rune{0xFE0F, 0xFE0F, 'x'}))
+	if !errors.Is(err, ErrInvalidRune) || !strings.Contains(err.Error(), "position 2") {
+		t.Fatalf("expected an invalid rune at position 2, got %v", err)
+	}
+
+	ambiguous := testAlphabet().Symbols()
+	ambiguous.Emojis[1] = "☀‍☁"
+	ambiguous.Emojis[2] = "☀☁"
+	enc, err := NewSymbolEncoding(ambiguous)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if _, err := enc.Lenient(); err == nil {
+		t.Fatalf("symbols that only differ by a ZWJ can't be decoded leniently")
+	}
+}
+
+func TestWrapAndGarbage(t *testing.T) {
+	a := testAlphabet().Symbols()
+	a.Emojis[1] = "☀️"
+	enc, err := NewSymbolEncoding(a)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	input := []byte{0x00, 0x40, 0x10, 0x04, 0x01, 0x00, 0x40}
+	wrapped := enc.EncodeWrapped(input, 3)
+	lines := strings.Split(wrapped, "\n")
+	// 8 symbols is 3, 3 and 2, and the sun keeps its VS16
+	if len(lines) != 3 || lines[0] != "☀️☀️☀️" || lines[2] != "☕☕" {
+		t.Fatalf("unexpected wrapping %q", wrapped)
+	}
+	decoded, err := enc.Decode(wrapped)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Fatalf("wrapped round trip gave %x %v", decoded, err)
+	}
+
+	garbage := "-- " + lines[0] + " --\n> " + lines[1] + lines[2] + "!"
+	if _, err := enc.Decode(garbage); !errors.Is(err, ErrInvalidRune) {
+		t.Fatalf("garbage should be an error by default, got %v", err)
+	}
+	decoded, stripped, err := enc.IgnoreGarbage().DecodeStripped(garbage)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Fatalf("ignoring garbage gave %x %v", decoded, err)
+	}
+	if len(stripped) != 9 || stripped[0].Pos != 0 || stripped[8].Rune != '!' {
+		t.Fatalf("expected the 9 garbage runes, got %v", stripped)
+	}
+
+	// a sun missing its VS16 is garbage unless lenient too
+	lenient, err := enc.Lenient()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	sun := strings.Replace(garbage, "☀️", "☀", 1)
+	if decoded, _, err := lenient.IgnoreGarbage().DecodeStripped(sun); err != nil || !bytes.Equal(decoded, input) {
+		t.Fatalf("lenient and ignoring garbage gave %x %v", decoded, err)
+	}
+	if _, _, err := enc.IgnoreGarbage().DecodeStripped(sun); err == nil {
+		t.Fatalf("dropping the sun should lose a symbol")
+	}
+}
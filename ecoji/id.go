@@ -0,0 +1,109 @@
+package ecoji
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// IDSizes are the id lengths EncodeHash and DecodeHash take: UUIDs and MD5,
+// SHA-1 and SHA-256
+var IDSizes = []int{16, 20, 32}
+
+// ErrIDSize is returned for an id that isn't one of IDSizes long
+var ErrIDSize = errors.New("ecoji: unsupported id size")
+
+// IDLength is how many symbols an id of size bytes always encodes to, 4
+// for every 5 bytes or part of 5. The last group of a 16 or 32 byte id is
+// padded, so every id of a size has its padding in the same place.
+func IDLength(size int) int {
+	return (size + 4) / 5 * 4
+}
+
+func checkIDSize(size int) error {
+	for _, s := range IDSizes {
+		if s == size {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d bytes", ErrIDSize, size)
+}
+
+// EncodeUUID encodes a UUID as 16 symbols, the last 3 of them padding
+func (e *Encoding) EncodeUUID(id [16]byte) string {
+	return e.Encode(id[:])
+}
+
+// DecodeUUID decodes what EncodeUUID made, anything that isn't exactly 16
+// bytes is an error
+func (e *Encoding) DecodeUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	decoded, err := e.Decode(s)
+	if err != nil {
+		return id, err
+	}
+	if len(decoded) != len(id) {
+		return id, fmt.Errorf("%w: %d bytes, a UUID is %d", ErrIDSize, len(decoded), len(id))
+	}
+	copy(id[:], decoded)
+	return id, nil
+}
+
+// EncodeHash encodes a digest or id that's one of IDSizes long, so it always
+// comes out IDLength(len(sum)) symbols
+func (e *Encoding) EncodeHash(sum []byte) (string, error) {
+	if err := checkIDSize(len(sum)); err != nil {
+		return "", err
+	}
+	return e.Encode(sum), nil
+}
+
+// DecodeHash decodes what EncodeHash made, the padding says how long it was
+func (e *Encoding) DecodeHash(s string) ([]byte, error) {
+	decoded, err := e.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIDSize(len(decoded)); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// OrderError is two ids whose encodings don't sort the way they do
+type OrderError struct {
+	A, B               []byte
+	EncodedA, EncodedB string
+}
+
+func (err *OrderError) Error() string {
+	return fmt.Sprintf("ecoji: %x sorts before %x but %s doesn't sort before %s", err.A, err.B, err.EncodedA, err.EncodedB)
+}
+
+// CheckOrder encodes ids and makes sure the encodings, compared as strings,
+// sort the same way the ids do as bytes. It returns an *OrderError for the
+// first pair that doesn't. Only ids of the same length are comparable, like
+// a column of UUIDs, ids of different lengths are an ErrIDSize.
+func (e *Encoding) CheckOrder(ids [][]byte) error {
+	for _, id := range ids {
+		if len(id) != len(ids[0]) {
+			return fmt.Errorf("%w: can't compare the order of %d and %d byte ids", ErrIDSize, len(ids[0]), len(id))
+		}
+	}
+	sorted := make([][]byte, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	var prev string
+	for i, id := range sorted {
+		encoded := e.Encode(id)
+		if i > 0 {
+			cmp := bytes.Compare(sorted[i-1], id)
+			if cmp == 0 && encoded != prev || cmp < 0 && prev >= encoded {
+				return &OrderError{A: sorted[i-1], B: id, EncodedA: prev, EncodedB: encoded}
+			}
+		}
+		prev = encoded
+	}
+	return nil
+}
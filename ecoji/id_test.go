@@ -0,0 +1,88 @@
+package ecoji
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIDs(t *testing.T) {
+	enc, err := NewEncoding(testAlphabet())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+
+	var uuid [16]byte
+	rnd.Read(uuid[:])
+	encoded := enc.EncodeUUID(uuid)
+	if n := utf8.RuneCountInString(encoded); n != IDLength(16) || n != 16 {
+		t.Fatalf("a UUID should be 16 symbols, got %d", n)
+	}
+	if decoded, err := enc.DecodeUUID(encoded); err != nil || decoded != uuid {
+		t.Fatalf("UUID round trip gave %x %v", decoded, err)
+	}
+
+	for _, size := range IDSizes {
+		sum := make([]byte, size)
+		rnd.Read(sum)
+		encoded, err := enc.EncodeHash(sum)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if n := utf8.RuneCountInString(encoded); n != IDLength(size) {
+			t.Fatalf("%d bytes should be %d symbols, got %d", size, IDLength(size), n)
+		}
+		if decoded, err := enc.DecodeHash(encoded); err != nil || !bytes.Equal(decoded, sum) {
+			t.Fatalf("%d byte round trip gave %x %v", size, decoded, err)
+		}
+	}
+
+	if _, err := enc.EncodeHash(make([]byte, 15)); !errors.Is(err, ErrIDSize) {
+		t.Fatalf("15 bytes should be %v, got %v", ErrIDSize, err)
+	}
+	if _, err := enc.DecodeHash(enc.Encode([]byte("abc"))); !errors.Is(err, ErrIDSize) {
+		t.Fatalf("3 bytes should be %v, got %v", ErrIDSize, err)
+	}
+	if _, err := enc.DecodeUUID(enc.Encode(make([]byte, 20))); !errors.Is(err, ErrIDSize) {
+		t.Fatalf("20 bytes isn't a UUID, got %v", err)
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	var ids [][]byte
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		id := make([]byte, 16)
+		rnd.Read(id)
+		ids = append(ids, id, id)
+	}
+
+	// the test emojis are in code point order, and every UUID has its
+	// padding in the same place, so the order holds
+	enc, err := NewEncoding(testAlphabet())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if err := enc.CheckOrder(ids); err != nil {
+		t.Fatalf("order should be preserved: %v", err)
+	}
+
+	a := testAlphabet()
+	a.Emojis[0], a.Emojis[1023] = a.Emojis[1023], a.Emojis[0]
+	swapped, err := NewEncoding(a)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var order *OrderError
+	if err := swapped.CheckOrder(ids); !errors.As(err, &order) || bytes.Compare(order.A, order.B) >= 0 {
+		t.Fatalf("swapping the first and last emoji should break the order, got %v", err)
+	}
+
+	mixed := append(ids, make([]byte, 20))
+	if err := enc.CheckOrder(mixed); !errors.Is(err, ErrIDSize) {
+		t.Fatalf("ids of different sizes should be %v, got %v", ErrIDSize, err)
+	}
+}
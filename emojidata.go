@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// emoji-test.txt is from unicode.org, trimmed down to the short sequences we
+// care about
+//
+//go:embed emoji-test.txt
+var emojiTestFile []byte
+
+// emojiInfo is one line of emoji-test.txt
+type emojiInfo struct {
+	CodePoints []rune
+	Status     string
+	Group      string
+	Subgroup   string
+	Version    string
+	Name       string
+}
+
+var (
+	emojiDataOnce sync.Once
+	emojiData     []emojiInfo
+	emojiByRune   map[rune]emojiInfo
+	emojiBySeq    map[string]emojiInfo
+)
+
+// parseEmojiTest parses lines like
+//
+//	1F600 ; fully-qualified # 😀 E1.0 grinning face
+func parseEmojiTest(buf []byte) []emojiInfo {
+	var infos []emojiInfo
+	var group, subgroup string
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# group:"):
+			group = strings.TrimSpace(strings.TrimPrefix(line, "# group:"))
+			continue
+		case strings.HasPrefix(line, "# subgroup:"):
+			subgroup = strings.TrimSpace(strings.TrimPrefix(line, "# subgroup:"))
+			continue
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+		semi := strings.Index(line, ";")
+		hash := strings.Index(line, "#")
+		if semi < 0 || hash < semi {
+			continue
+		}
+		info := emojiInfo{
+			Status:   strings.TrimSpace(line[semi+1 : hash]),
+			Group:    group,
+			Subgroup: subgroup,
+		}
+		for _, hexStr := range strings.Fields(line[:semi]) {
+			n, err := strconv.ParseInt(hexStr, 16, 32)
+			if err != nil {
+				continue
+			}
+			info.CodePoints = append(info.CodePoints, rune(n))
+		}
+		// the comment is the emoji itself, the version and then the name
+		comment := strings.Fields(line[hash+1:])
+		if len(comment) >= 3 {
+			info.Version = comment[1]
+			info.Name = strings.Join(comment[2:], " ")
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func loadEmojiData() {
+	emojiDataOnce.Do(func() {
+		emojiData = parseEmojiTest(emojiTestFile)
+		emojiByRune = make(map[rune]emojiInfo)
+		emojiBySeq = make(map[string]emojiInfo)
+		for _, info := range emojiData {
+			emojiBySeq[string(info.CodePoints)] = info
+			r := info.CodePoints[0]
+			if _, ok := emojiByRune[r]; ok && len(info.CodePoints) > 1 {
+				continue
+			}
+			// a bare code point wins over its FE0F sequence, anything else
+			// (keycaps, flags) is only looked up by the whole sequence
+			if len(info.CodePoints) == 1 || (len(info.CodePoints) == 2 && info.CodePoints[1] == 0xFE0F) {
+				emojiByRune[r] = info
+			}
+		}
+	})
+}
+
+// lookupEmoji finds the emoji-test.txt entry for a single code point
+func lookupEmoji(r rune) (emojiInfo, bool) {
+	loadEmojiData()
+	info, ok := emojiByRune[r]
+	return info, ok
+}
+
+// lookupSequence finds the emoji-test.txt entry for a whole sequence
+func lookupSequence(seq []rune) (emojiInfo, bool) {
+	loadEmojiData()
+	info, ok := emojiBySeq[string(seq)]
+	return info, ok
+}
+
+// sequenceFor is the fully-qualified emoji r and U+FE0F make, for a v1 rune
+// that's only an emoji with the variation selector, or nil
+func sequenceFor(r rune) []rune {
+	seq := []rune{r, 0xFE0F}
+	if info, ok := lookupSequence(seq); ok && info.Status == "fully-qualified" {
+		return seq
+	}
+	return nil
+}
+
+// subgroupOf is "group / subgroup", or "unknown" for runes that aren't in
+// emoji-test.txt like the lone regional indicators
+func subgroupOf(r rune) string {
+	info, ok := lookupEmoji(r)
+	if !ok {
+		return "unknown"
+	}
+	return info.Group + " / " + info.Subgroup
+}
+
+// offlineName is the emoji-test.txt name, for when we can't (or don't want
+// to) ask emojipedia
+func offlineName(r rune) (string, error) {
+	info, ok := lookupEmoji(r)
+	if !ok {
+		return "", fmt.Errorf("%x: %w in emoji-test.txt", r, ErrNameNotFound)
+	}
+	return info.Name, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/robindiddams/emojidict"
+)
+
+// exclusion is a list of emojis we'd rather not have in the alphabet.
+// When there aren't enough candidates the lowest priority ones are let back
+// in first.
+type exclusion struct {
+	Name     string
+	Priority int
+	Runes    [][]rune
+}
+
+var exclusions = []exclusion{
+	{Name: "redundant", Priority: 1, Runes: redundantRunes},
+	{Name: "new", Priority: 2, Runes: newEmojis},
+	{Name: "people", Priority: 3, Runes: peopleRunes},
+}
+
+// removal is how many candidates one reason took out of the pool. Either
+// they're an exclusion, or they're already in the alphabet, as a v1 emoji it
+// keeps or an override.
+type removal struct {
+	Name      string
+	Count     int
+	Exclusion bool
+}
+
+// removedCounts adds up the candidates already in the alphabet and the ones
+// exclusions took out separately
+func removedCounts(removed []removal) (inAlphabet, excluded int) {
+	for _, r := range removed {
+		if r.Exclusion {
+			excluded += r.Count
+		} else {
+			inAlphabet += r.Count
+		}
+	}
+	return inAlphabet, excluded
+}
+
+// relaxedRune is an excluded emoji we let back in to complete the alphabet
+type relaxedRune struct {
+	Rune      rune
+	Exclusion string
+}
+
+// poolExhaustedError is returned when there weren't enough candidates for
+// every slot that needs replacing
+type poolExhaustedError struct {
+	Unfilled int
+	Removed  []removal
+}
+
+func (e *poolExhaustedError) Error() string {
+	var inAlphabet, excluded []string
+	for _, r := range e.Removed {
+		if r.Exclusion {
+			excluded = append(excluded, fmt.Sprintf("%s %d", r.Name, r.Count))
+		} else {
+			inAlphabet = append(inAlphabet, fmt.Sprintf("%s %d", r.Name, r.Count))
+		}
+	}
+	return fmt.Sprintf("ran out of candidates, %d slots could not be filled (already in the alphabet: %s; excluded: %s)",
+		e.Unfilled, strings.Join(inAlphabet, ", "), strings.Join(excluded, ", "))
+}
+
+// candidatePool is every single code point emoji we're allowed to pick from,
+// in emojidict order, and how many each reason removed
+func candidatePool(ecojiset []rune, ov overrides, excl []exclusion) ([]rune, []removal) {
+	var singlePointRunesStack []rune
+	for _, emoji := range emojidict.All {
+		if len(emoji) == 1 {
+			singlePointRunesStack = append(singlePointRunesStack, emoji[0])
+		}
+	}
+
+	var removed []removal
+	removeRunes := func(name string, runes []rune, exclusion bool) {
+		count := 0
+		for _, r := range runes {
+			for i, rr := range singlePointRunesStack {
+				if rr == r {
+					singlePointRunesStack = append(singlePointRunesStack[:i], singlePointRunesStack[i+1:]...)
+					count++
+					break
+				}
+			}
+		}
+		removed = append(removed, removal{Name: name, Count: count, Exclusion: exclusion})
+	}
+
+	removeRunes("v1 set", ecojiset, false)
+	removeRunes("padding", paddingRunes, false)
+	for _, e := range excl {
+		var runes []rune
+		for _, emoji := range e.Runes {
+			runes = append(runes, emoji[0])
+		}
+		removeRunes(e.Name, runes, true)
+	}
+	var overridden []rune
+	for _, override := range ov.Emojis {
+		overridden = append(overridden, override)
+	}
+	for _, override := range ov.Padding {
+		overridden = append(overridden, override)
+	}
+	removeRunes("overrides", overridden, false)
+
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].Count > removed[j].Count
+	})
+	return singlePointRunesStack, removed
+}
+
+// relaxPlan builds a plan, and while the pool runs short lets excluded
+// emojis back in, lowest priority first, until every slot is filled. Only
+// the emojis that end up filling a slot are recorded as relaxed.
+func relaxPlan(ecojiset []rune, ov overrides, sel Selector, opts planOptions) (plan, error) {
+	remaining := make([]exclusion, len(opts.Exclusions))
+	copy(remaining, opts.Exclusions)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Priority < remaining[j].Priority
+	})
+
+	// an excluded emoji only joins the pool once nothing else excludes it,
+	// and never if it's in the v1 set, padding or an override
+	eligible := make(map[rune]bool)
+	all, _ := candidatePool(ecojiset, ov, nil)
+	for _, r := range all {
+		eligible[r] = true
+	}
+	excludedBy := make(map[rune]int)
+	for _, e := range remaining {
+		for _, emoji := range e.Runes {
+			excludedBy[emoji[0]]++
+		}
+	}
+
+	var relaxed []relaxedRune
+	for {
+		opts.Exclusions = remaining
+		p, err := buildPlanWith(ecojiset, ov, sel, opts)
+		exhausted, ok := err.(*poolExhaustedError)
+		if !ok {
+			p.Relaxed = filledBy(p, relaxed)
+			return p, err
+		}
+		need := exhausted.Unfilled
+		for i := range remaining {
+			for need > 0 && len(remaining[i].Runes) > 0 {
+				r := remaining[i].Runes[0][0]
+				remaining[i].Runes = remaining[i].Runes[1:]
+				excludedBy[r]--
+				if eligible[r] && excludedBy[r] == 0 {
+					relaxed = append(relaxed, relaxedRune{Rune: r, Exclusion: remaining[i].Name})
+					need--
+				}
+			}
+		}
+		if need == exhausted.Unfilled {
+			// nothing left to let back in
+			return p, err
+		}
+	}
+}
+
+// filledBy is the relaxed runes that got a slot in p
+func filledBy(p plan, relaxed []relaxedRune) []relaxedRune {
+	used := make(map[rune]bool)
+	for _, slots := range [][]slot{p.Padding, p.Emojis} {
+		for _, s := range slots {
+			if s.replaced() {
+				used[s.Replacement] = true
+			}
+		}
+	}
+	var filled []relaxedRune
+	for _, r := range relaxed {
+		if used[r.Rune] {
+			filled = append(filled, r)
+		}
+	}
+	return filled
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPoolExhausted(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	pool, _ := candidatePool(ecojiset, builtinOverrides(), exclusions)
+
+	// exclude everything that's left, with a few of them being the least
+	// important so they're the first ones let back in. Spare starts with a
+	// v1 emoji and an override, letting those back in adds nothing.
+	var override rune
+	for _, r := range builtinOverrides().Emojis {
+		override = r
+		break
+	}
+	spare := [][]rune{{ecojiset[0]}, {override}}
+	var everything [][]rune
+	for i, r := range pool {
+		if i < 3 {
+			spare = append(spare, []rune{r})
+		} else {
+			everything = append(everything, []rune{r})
+		}
+	}
+	excl := append([]exclusion{
+		{Name: "everything", Priority: 5, Runes: everything},
+		{Name: "spare", Priority: 0, Runes: spare},
+	}, exclusions...)
+
+	_, err := buildPlanWith(ecojiset, builtinOverrides(), sequentialSelector{}, planOptions{Exclusions: excl})
+	var exhausted *poolExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("should run out of candidates, got %v", err)
+	}
+	if exhausted.Unfilled != len(pool)-30 {
+		t.Fatalf("wrong number of unfilled slots %d", exhausted.Unfilled)
+	}
+	if exhausted.Removed[0].Name != "v1 set" {
+		t.Fatalf("the v1 set should remove the most, got %v", exhausted.Removed)
+	}
+	// the v1 set is already in the alphabet, it isn't an exclusion
+	if exhausted.Removed[0].Exclusion || !strings.Contains(err.Error(), "excluded: everything") {
+		t.Fatalf("exclusions should be counted apart from the alphabet, got %v", err)
+	}
+
+	p, err := relaxPlan(ecojiset, builtinOverrides(), sequentialSelector{}, planOptions{Exclusions: excl})
+	if err != nil {
+		t.Fatalf("relaxing should fill the alphabet, got %v", err)
+	}
+	validation := p.validation()
+	if !validation.ok() {
+		t.Fatalf("relaxed plan has problems: %v", validation.errors())
+	}
+	var relaxed int
+	for _, f := range validation.Findings {
+		if f.Rule == "relaxed" {
+			relaxed++
+		}
+	}
+	if relaxed != len(p.Relaxed) {
+		t.Fatalf("should warn about every relaxed rune, got %d", relaxed)
+	}
+	if p.Relaxed[0].Exclusion != "spare" {
+		t.Fatalf("lowest priority exclusion should be relaxed first, got %v", p.Relaxed)
+	}
+	filled := make(map[rune]bool)
+	for _, s := range p.Emojis {
+		if s.replaced() {
+			filled[s.Replacement] = true
+		}
+	}
+	for _, r := range p.Relaxed {
+		if r.Rune == ecojiset[0] || r.Rune == override || !filled[r.Rune] {
+			t.Fatalf("%x was let back in but didn't fill a slot", r.Rune)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// validFlags are the regional indicator pairs that render as a country flag
+func validFlags() map[string]bool {
+	loadEmojiData()
+	flags := make(map[string]bool)
+	for _, info := range emojiData {
+		if info.Subgroup == "country-flag" && len(info.CodePoints) == 2 {
+			flags[string(info.CodePoints)] = true
+		}
+	}
+	return flags
+}
+
+// countFlags finds the regional indicator pairs in s. Like a renderer, it
+// pairs them up from the start of each run, so 3 in a row is 1 pair and a
+// loose one. It returns the number of pairs and the ones that are real flags.
+func countFlags(s string, valid map[string]bool) (pairs int, flags []string) {
+	runes := []rune(s)
+	for i := 0; i+1 < len(runes); i++ {
+		if !isRegionalIndicator(runes[i]) || !isRegionalIndicator(runes[i+1]) {
+			continue
+		}
+		pairs++
+		if pair := string(runes[i : i+2]); valid[pair] {
+			flags = append(flags, pair)
+		}
+		i++
+	}
+	return pairs, flags
+}
+
+type flagExample struct {
+	Line    int
+	Flags   int
+	Encoded string
+}
+
+type flagStats struct {
+	Lines              int
+	Runes              int
+	RegionalIndicators int
+	Pairs              int
+	Flags              int
+	LinesWithFlags     int
+	ByFlag             map[string]int
+	Worst              []flagExample
+}
+
+// analyzeFlags counts the flags in every encoded line, keeping the worst
+// few lines as examples
+func analyzeFlags(encoded []string, worst int) flagStats {
+	valid := validFlags()
+	st := flagStats{ByFlag: make(map[string]int)}
+	for i, line := range encoded {
+		st.Lines++
+		for _, r := range line {
+			st.Runes++
+			if isRegionalIndicator(r) {
+				st.RegionalIndicators++
+			}
+		}
+		pairs, flags := countFlags(line, valid)
+		st.Pairs += pairs
+		st.Flags += len(flags)
+		for _, f := range flags {
+			st.ByFlag[f]++
+		}
+		if len(flags) > 0 {
+			st.LinesWithFlags++
+			st.Worst = append(st.Worst, flagExample{Line: i + 1, Flags: len(flags), Encoded: line})
+		}
+	}
+	sort.SliceStable(st.Worst, func(i, j int) bool {
+		return st.Worst[i].Flags > st.Worst[j].Flags
+	})
+	if len(st.Worst) > worst {
+		st.Worst = st.Worst[:worst]
+	}
+	return st
+}
+
+func (st flagStats) write(w io.Writer, title string) {
+	fmt.Fprintf(w, "## %s\n\n", title)
+	fmt.Fprintf(w, "lines: %d\n", st.Lines)
+	fmt.Fprintf(w, "encoded runes: %d\n", st.Runes)
+	fmt.Fprintf(w, "regional indicators: %d\n", st.RegionalIndicators)
+	fmt.Fprintf(w, "adjacent pairs: %d\n", st.Pairs)
+	fmt.Fprintf(w, "valid flags: %d\n", st.Flags)
+	if st.Lines > 0 {
+		fmt.Fprintf(w, "lines with a flag: %d (%.2f%%)\n", st.LinesWithFlags, float64(st.LinesWithFlags)*100/float64(st.Lines))
+	}
+
+	type flagCount struct {
+		flag  string
+		count int
+	}
+	var counts []flagCount
+	for f, n := range st.ByFlag {
+		counts = append(counts, flagCount{f, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].flag < counts[j].flag
+	})
+	if len(counts) > 0 {
+		fmt.Fprintf(w, "\n| flag | count |\n|------|-------|\n")
+		for _, c := range counts {
+			fmt.Fprintf(w, "| %s | %d |\n", c.flag, c.count)
+		}
+	}
+	if len(st.Worst) > 0 {
+		fmt.Fprintf(w, "\n| line | flags | encoded |\n|------|-------|---------|\n")
+		for _, ex := range st.Worst {
+			fmt.Fprintf(w, "| %d | %d | %s |\n", ex.Line, ex.Flags, ex.Encoded)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func readLines(paths []string) ([]string, error) {
+	var lines []string
+	read := func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		return scanner.Err()
+	}
+	if len(paths) == 0 {
+		return lines, read(os.Stdin)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = read(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func runFlags(args []string) {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	encoded := flags.Bool("encoded", false, "the input is already v1 ecoji text, one encoding per line")
+	worst := flags.Int("worst", 10, "how many of the worst lines to show")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer flags [flags] [file...]")
+		fmt.Fprintln(flags.Output(), "counts regional indicator pairs that render as flags, each input line is encoded on its own")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	lines, err := readLines(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *encoded {
+		analyzeFlags(lines, *worst).write(os.Stdout, "v1 ecoji text")
+		return
+	}
+	for _, name := range []string{"v1", "v2"} {
+		enc, err := loadEncoding(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var out []string
+		for _, line := range lines {
+			out = append(out, enc.Encode([]byte(line)))
+		}
+		analyzeFlags(out, *worst).write(os.Stdout, name)
+	}
+}
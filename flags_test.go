@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCountFlags(t *testing.T) {
+	valid := validFlags()
+	// 🇸🇪 then a loose 🇸 before the 🐖, 🇿🇿 isn't a country
+	s := string([]rune{0x1F1F8, 0x1F1EA, 0x1F1F8, 0x1F416, 0x1F1FF, 0x1F1FF})
+	pairs, flags := countFlags(s, valid)
+	if pairs != 2 || len(flags) != 1 || flags[0] != "\U0001F1F8\U0001F1EA" {
+		t.Fatalf("expected 2 pairs and 1 flag, got %d %q", pairs, flags)
+	}
+}
+
+func TestV2HasNoFlags(t *testing.T) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	var v1Lines, v2Lines []string
+	for i := 0; i < 2000; i++ {
+		buf := make([]byte, 40)
+		rnd.Read(buf)
+		v1Lines = append(v1Lines, v1.Encode(buf))
+		v2Lines = append(v2Lines, v2.Encode(buf))
+	}
+	if st := analyzeFlags(v1Lines, 5); st.Flags == 0 {
+		t.Fatalf("v1 should make some flags")
+	}
+	if st := analyzeFlags(v2Lines, 5); st.RegionalIndicators != 0 {
+		t.Fatalf("v2 shouldn't have any regional indicators, got %d", st.RegionalIndicators)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// generateConfig is everything the generator reads, so it can run against
+// fixtures without touching the network or the working directory
+type generateConfig struct {
+	Mapping   []byte
+	Overrides overrides
+	Selector  Selector
+	Relax     bool
+	Sequences bool
+	// Confusables excludes all but one of each cluster of look alike
+	// candidates
+	Confusables bool
+	// Baseline is a previous alphabet to change as little as possible,
+	// only slots whose replacement isn't a candidate anymore get a new one
+	Baseline *ecoji.SymbolAlphabet
+	Name     nameLookup
+	// UnknownNames carries on with unknownName when a name can't be looked
+	// up, instead of failing the run
+	UnknownNames bool
+	Log          io.Writer
+}
+
+// generated is everything the generator writes
+type generated struct {
+	Plan     plan
+	Markdown []byte
+	Emojis   []byte
+	Padding  []byte
+	Names    func(rune) string
+}
+
+// generate builds and validates the plan and renders the outputs. Progress
+// and findings go to cfg.Log.
+func generate(cfg generateConfig) (generated, error) {
+	var g generated
+	ecojiset, err := parseMapping(cfg.Mapping)
+	if err != nil {
+		return g, err
+	}
+	if len(ecojiset) != len(ecoji.Alphabet{}.Emojis) {
+		return g, fmt.Errorf("%w: expected %d emojis, got %d", ErrMappingMalformed, len(ecoji.Alphabet{}.Emojis), len(ecojiset))
+	}
+	ov := builtinOverrides().merge(cfg.Overrides)
+	if report := checkOverrides(ecojiset, ov); len(report.Findings) > 0 {
+		report.writeText(cfg.Log)
+		if err := report.err(); err != nil {
+			return g, err
+		}
+	}
+
+	opts := defaultPlanOptions()
+	opts.Sequences = cfg.Sequences
+	var clusters []confusableCluster
+	if cfg.Confusables {
+		var confusable exclusion
+		confusable, clusters = confusableExclusion(ecojiset, ov, opts.Exclusions)
+		opts.Exclusions = append(append([]exclusion{}, opts.Exclusions...), confusable)
+		for _, c := range clusters {
+			if c.ExcludedBy != "" {
+				fmt.Fprintf(cfg.Log, "confusable: %s, all excluded as %s\n", string(c.Members), c.ExcludedBy)
+				continue
+			}
+			fmt.Fprintf(cfg.Log, "confusable: %s, kept %c (%x)\n", string(c.Members), c.Kept, c.Kept)
+		}
+	}
+
+	pool, removed := candidatePool(ecojiset, ov, opts.Exclusions)
+	inAlphabet, excluded := removedCounts(removed)
+	fmt.Fprintln(cfg.Log, "already in the alphabet:", inAlphabet)
+	fmt.Fprintln(cfg.Log, "excluded:", excluded)
+	fmt.Fprintln(cfg.Log, "remaining:", len(pool))
+
+	sel := cfg.Selector
+	if cfg.Baseline != nil {
+		sel = stableSelector{baseline: *cfg.Baseline, next: sel}
+	}
+	var p plan
+	if cfg.Relax {
+		p, err = relaxPlan(ecojiset, ov, sel, opts)
+	} else {
+		p, err = buildPlanWith(ecojiset, ov, sel, opts)
+	}
+	if err != nil {
+		return g, err
+	}
+	p.Confusables = clusters
+	for _, r := range p.Relaxed {
+		fmt.Fprintf(cfg.Log, "let %c (%x) back in from %s\n", r.Rune, r.Rune, r.Exclusion)
+	}
+
+	validation := p.validation()
+	rendering := checkSymbolRendering(p.finalSymbols(), p.finalPaddingSymbols(), 1000, 1)
+	validation.Findings = append(validation.Findings, rendering.Findings...)
+	if !validation.ok() {
+		validation.writeText(cfg.Log)
+		return g, validation.err()
+	}
+
+	name := lenientNames(cfg.Name, cfg.Log)
+	if !cfg.UnknownNames {
+		if name, err = resolveNames(cfg.Name, planRunes(p)); err != nil {
+			return g, err
+		}
+	}
+
+	for _, s := range p.Padding {
+		if s.replaced() {
+			fmt.Fprintf(cfg.Log, "replacement padding emoji (%c), using %x ( %c )  %s\n", s.Original, s.Replacement, s.Replacement, name(s.Replacement))
+		}
+	}
+	for _, s := range p.Emojis {
+		if s.replaced() {
+			fmt.Fprintf(cfg.Log, "replacemed emoji %d (%c), with %x ( %c )  %s\n", s.Index, s.Original, s.Replacement, s.Replacement, name(s.Replacement))
+		}
+	}
+
+	if cfg.Baseline != nil {
+		a, err := newSymbolAlphabet(p.finalSymbols(), p.finalPaddingSymbols())
+		if err != nil {
+			return g, err
+		}
+		fmt.Fprintln(cfg.Log, "changed from the baseline:")
+		writeDiff(cfg.Log, "text", diffAlphabets(*cfg.Baseline, a))
+	}
+
+	var md bytes.Buffer
+	writeMarkdown(&md, p, name)
+	fmt.Fprintln(cfg.Log, "unused:", len(p.Unused))
+	st := p.stats()
+	fmt.Fprintf(cfg.Log, "kept as a sequence: %d of %d\n", st.Retained, st.Retainable)
+
+	g.Plan = p
+	g.Markdown = md.Bytes()
+	g.Emojis = formatSymbolFile(p.finalSymbols())
+	g.Padding = formatSymbolFile(p.finalPaddingSymbols())
+	g.Names = name
+	return g, nil
+}
+
+// planRunes are the runes the outputs need names for, the replacements and
+// the unused pool
+func planRunes(p plan) []rune {
+	var runes []rune
+	for _, slots := range [][]slot{p.Padding, p.Emojis} {
+		for _, s := range slots {
+			if s.replaced() {
+				runes = append(runes, s.Replacement)
+			}
+		}
+	}
+	return append(runes, p.Unused...)
+}
+
+// writeOutput writes buf to path, - is stdout and an empty path skips it
+func writeOutput(path string, buf []byte) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		_, err := os.Stdout.Write(buf)
+		return err
+	}
+	return os.WriteFile(path, buf, 0644)
+}
+
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	strategy := flags.String("strategy", "sequential", "how to pick replacements: "+selectorNames())
+	seed := flags.Int64("seed", 1, "seed for the random strategy")
+	relax := flags.Bool("relax", false, "if we run out of candidates, let excluded emojis back in, lowest priority first")
+	sequences := flags.Bool("sequences", false, "keep v1 emojis that need U+FE0F as that sequence instead of replacing them")
+	confusables := flags.Bool("confusables", false, "keep only one of each cluster of look alike candidates, like coloured circles")
+	stable := flags.String("stable", "", "previous alphabet to keep replacements from where they're still candidates, like emojis.txt, a registered name or a spec json")
+	mappingPath := flags.String("mapping", defaultMappingFile, "v1 mapping from keith-turner/ecoji")
+	overridesPath := flags.String("overrides", overridesFile, "overrides file, missing is fine")
+	emojisPath := flags.String("emojis", "emojis.txt", "where to write the 1024 emojis")
+	paddingPath := flags.String("padding", paddingFile, "where to write the 5 padding runes")
+	markdownPath := flags.String("markdown", "-", "where to write the markdown tables, - for stdout, empty to skip")
+	reportPath := flags.String("report", "", "where to write the html report, like report.html, none by default")
+	provider := flags.String("names", "emojipedia", "where emoji names come from: "+nameProviderNames())
+	cacheDir := flags.String("cache", defaultCacheDir, "directory emojipedia names are cached in")
+	unknown := flags.Bool("unknown-names", false, "use \""+unknownName+"\" and carry on when a name can't be looked up")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer generate [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	sel, err := newSelector(*strategy, *seed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	names, err := newNameProvider(*provider, *cacheDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var baseline *ecoji.SymbolAlphabet
+	if *stable != "" {
+		a := mustLoadEncoding(*stable).Symbols()
+		baseline = &a
+	}
+
+	buf, err := getMapping(*mappingPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fileOverrides, err := readOverrides(*overridesPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	g, err := generate(generateConfig{
+		Mapping:      buf,
+		Overrides:    fileOverrides,
+		Selector:     sel,
+		Relax:        *relax,
+		Sequences:    *sequences,
+		Confusables:  *confusables,
+		Baseline:     baseline,
+		Name:         names,
+		UnknownNames: *unknown,
+		Log:          os.Stderr,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "writing final set")
+	for _, out := range []struct {
+		path string
+		buf  []byte
+	}{{*markdownPath, g.Markdown}, {*emojisPath, g.Emojis}, {*paddingPath, g.Padding}} {
+		if err := writeOutput(out.path, out.buf); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if *reportPath != "" {
+		fmt.Fprintln(os.Stderr, "writing report")
+		if err := writeReportFile(*reportPath, g.Plan, g.Names); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
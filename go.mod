@@ -1,5 +1,10 @@
 module github.com/robindiddams/ecojifixer
 
-go 1.16
+go 1.18
 
-require github.com/robindiddams/emojidict v0.0.1
+require (
+	github.com/rivo/uniseg v0.4.7
+	github.com/robindiddams/emojidict v0.0.1
+)
+
+require github.com/robindiddams/go-trie v0.0.1 // indirect
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// TestGolden runs the whole generator against mapping.txt, the vendored
+// emoji-test.txt and a fixed override config, and compares what it writes
+// with the committed outputs. If a change is supposed to move slots around,
+// rerun with -update and check the diff.
+func TestGolden(t *testing.T) {
+	mapping, err := getMapping(defaultMappingFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	ov, err := readOverrides(filepath.Join("testdata", "golden", "overrides.txt"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for _, strategy := range []string{"sequential", "similarity"} {
+		t.Run(strategy, func(t *testing.T) {
+			sel, err := newSelector(strategy, 1)
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			g, err := generate(generateConfig{
+				Mapping:   mapping,
+				Overrides: ov,
+				Selector:  sel,
+				Name:      offlineName,
+				Log:       io.Discard,
+			})
+			if err != nil {
+				t.Fatalf("error %v", err)
+			}
+			for name, got := range map[string][]byte{
+				"suggested.md": g.Markdown,
+				"emojis.txt":   g.Emojis,
+				"padding.txt":  g.Padding,
+			} {
+				checkGolden(t, filepath.Join("testdata", "golden", strategy, name), got)
+			}
+		})
+	}
+}
+
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error %v, run with -update to create it", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	gotLines, wantLines := bytes.Split(got, []byte("\n")), bytes.Split(want, []byte("\n"))
+	var changed int
+	first := -1
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		if i >= len(gotLines) || i >= len(wantLines) || !bytes.Equal(gotLines[i], wantLines[i]) {
+			changed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	t.Fatalf("%s is out of date, %d lines changed starting at line %d, rerun with -update if that's expected", path, changed, first+1)
+}
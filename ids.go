@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// parseID reads an id written in hex, dashes and spaces are dropped so a
+// UUID can be pasted as it is
+func parseID(s string) ([]byte, error) {
+	s = strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s))
+	id, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q isn't a hex id: %w", s, err)
+	}
+	return id, nil
+}
+
+// formatUUID writes 16 bytes the way UUIDs usually are, 8-4-4-4-12
+func formatUUID(id []byte) string {
+	h := hex.EncodeToString(id)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func runIDs(args []string) {
+	c := newCodecFlags("ids", "encodes one hex UUID or digest per line, of 16, 20 or 32 bytes, or decodes them")
+	alphabet := c.flags.String("alphabet", "", "alphabet to use, a name from "+registryFile+", v1, v2 or the path to an emojis file, by default the newest final one that replaces v1")
+	draft := c.flags.Bool("draft", false, "allow encoding with an alphabet that isn't final in "+registryFile)
+	decode := c.flags.Bool("decode", false, "decode ecoji ids back to hex")
+	uuid := c.flags.Bool("uuid", false, "write decoded 16 byte ids as UUIDs, with dashes")
+	check := c.flags.Bool("check-order", false, "instead of encoding, check the encoded ids sort the same as the ids")
+	random := c.flags.Int("random", 0, "check the order of this many random ids instead of reading them")
+	size := c.flags.Int("size", 16, "size of the random ids")
+	c.flags.Parse(args)
+
+	// only encoding needs a final alphabet
+	enc := mustLoadEncoder(*alphabet, *draft || *decode || *check)
+	var lines []string
+	if *random == 0 {
+		var err error
+		if lines, err = readLines(c.flags.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	var out strings.Builder
+	switch {
+	case *check:
+		var ids [][]byte
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < *random; i++ {
+			id := make([]byte, *size)
+			rnd.Read(id)
+			ids = append(ids, id)
+		}
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			id, err := parseID(line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			ids = append(ids, id)
+		}
+		if err := enc.CheckOrder(ids); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(&out, "order of %d ids preserved\n", len(ids))
+	case *decode:
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			id, err := enc.DecodeHash(strings.TrimSpace(line))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if *uuid && len(id) == 16 {
+				fmt.Fprintln(&out, formatUUID(id))
+				continue
+			}
+			fmt.Fprintln(&out, hex.EncodeToString(id))
+		}
+	default:
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			id, err := parseID(line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			encoded, err := enc.EncodeHash(id)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(&out, encoded)
+		}
+	}
+	c.write([]byte(out.String()))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+func TestParseID(t *testing.T) {
+	id, err := parseID(" 123e4567-e89b-12d3-a456-426614174000\n")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(id) != 16 || formatUUID(id) != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Fatalf("unexpected id %x", id)
+	}
+	if _, err := parseID("not hex"); err == nil {
+		t.Fatalf("should fail on something that isn't hex")
+	}
+}
+
+// TestV1IDOrder checks v1 keeps ids of every size in order, it was designed
+// to sort and with a fixed length the padding always lines up
+func TestV1IDOrder(t *testing.T) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range ecoji.IDSizes {
+		var ids [][]byte
+		for i := 0; i < 2000; i++ {
+			id := make([]byte, size)
+			rnd.Read(id)
+			ids = append(ids, id)
+		}
+		// neighbours that only differ in the last byte
+		last := append([]byte{}, ids[0]...)
+		last[size-1] ^= 1
+		ids = append(ids, last)
+		if err := v1.CheckOrder(ids); err != nil {
+			t.Fatalf("%d byte ids: %v", size, err)
+		}
+		encoded, err := v1.EncodeHash(ids[0])
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if decoded, err := v1.DecodeHash(encoded); err != nil || !bytes.Equal(decoded, ids[0]) {
+			t.Fatalf("%d byte round trip gave %x %v", size, decoded, err)
+		}
+	}
+}
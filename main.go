@@ -1,13 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/robindiddams/emojidict"
 )
@@ -20,60 +22,36 @@ var paddingRunes = []rune{
 	0x1F64B,
 }
 
-func getCachedName(r rune) (string, bool) {
-	buf, err := os.ReadFile(fmt.Sprintf("cache/%x", r))
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", false
-		}
-		panic(err)
-	}
-	return string(buf), true
-}
-
-func saveNameToCache(r rune, name string) {
-	if err := os.WriteFile(fmt.Sprintf("cache/%x", r), []byte(name), 0644); err != nil {
-		fmt.Fprintln(os.Stderr, err, os.IsExist(err))
-	}
-}
-
-func getName(r rune) string {
-	if cached, found := getCachedName(r); found {
-		return cached
-	}
-	resp, err := http.Get(fmt.Sprintf("https://emojipedia.org/emoji/%c/", r))
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-	buf, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		panic(err)
-	}
-	str := string(buf)
-	match := regexp.MustCompile(`<title>(.*)</title>`).FindStringSubmatch(str)
-	name := strings.TrimSpace(strings.Replace(strings.Replace(match[1], string(r), "", 1), "Emoji", "", 1))
-	saveNameToCache(r, name)
-	return name
-}
+// ErrMappingMalformed means mapping.txt isn't the v1 mapping we expect
+var ErrMappingMalformed = errors.New("mapping malformed")
 
 func parseMapping(buf []byte) ([]rune, error) {
 	re := regexp.MustCompile(`\temojis\[\d+\] = 0x([0-9A-Z]+)\n`)
 	matches := re.FindAllSubmatch(buf, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no emojis found", ErrMappingMalformed)
+	}
 	var emojis []rune
 	for _, match := range matches {
 		hexStr := string(match[1])
-		n, err := strconv.ParseInt(hexStr, 16, 64)
+		n, err := strconv.ParseInt(hexStr, 16, 32)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", ErrMappingMalformed, err)
+		}
+		if !utf8.ValidRune(rune(n)) {
+			return nil, fmt.Errorf("%w: %s is not a valid rune", ErrMappingMalformed, hexStr)
 		}
 		emojis = append(emojis, rune(n))
 	}
 	return emojis, nil
 }
 
-func getMapping() ([]byte, error) {
-	buf, err := ioutil.ReadFile("mapping.txt")
+// defaultMappingFile is where the v1 mapping from keith-turner/ecoji lives
+// unless a command is told otherwise
+const defaultMappingFile = "mapping.txt"
+
+func getMapping(path string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -198,147 +176,66 @@ var paddingSelectionOverrides = map[int][]rune{
 	2: emojidict.RollerSkate,
 }
 
-func main() {
-	os.Mkdir("cache", 0777)
-	fmt.Fprintln(os.Stderr, "fetching mapping from keith-turner/ecoji")
-	buf, err := getMapping()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	ecojiset, err := parseMapping(buf)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	checkRune := func(r rune) bool {
-		for _, emoji := range emojidict.All {
-			if len(emoji) == 1 && emoji[0] == r {
-				return true
-			}
-		}
-		return false
-	}
-
-	// singlePointRunes := make(map[rune]bool)
-	var singlePointRunesStack []rune
-	for _, emoji := range emojidict.All {
-		if len(emoji) == 1 {
-			// singlePointRunes[emoji[0]] = true
-			singlePointRunesStack = append(singlePointRunesStack, emoji[0])
-		}
-	}
-
-	removeRune := func(r rune) {
-		for i, rr := range singlePointRunesStack {
-			if rr == r {
-				singlePointRunesStack = append(singlePointRunesStack[:i], singlePointRunesStack[i+1:]...)
-				return
-			}
-		}
-	}
-
-	for _, original := range ecojiset {
-		removeRune(original)
-	}
-	for _, originalPadding := range paddingRunes {
-		removeRune(originalPadding)
-	}
-	for _, redundant := range redundantRunes {
-		removeRune(redundant[0])
-	}
-	for _, new := range newEmojis {
-		removeRune(new[0])
-	}
-	for _, personEmoji := range peopleRunes {
-		removeRune(personEmoji[0])
-	}
-	for _, override := range selectionOverrides {
-		removeRune(override[0])
-	}
-	for _, override := range paddingSelectionOverrides {
-		removeRune(override[0])
-	}
-
-	fmt.Fprintln(os.Stderr, "remaining:", len(singlePointRunesStack))
-
-	var index int
-	getReplacement := func(isPadding bool, setIndex int) rune {
-		if isPadding {
-			if override, ok := paddingSelectionOverrides[setIndex]; ok {
-				return override[0]
-			}
-		} else {
-			if override, ok := selectionOverrides[setIndex]; ok {
-				return override[0]
-			}
-		}
-		if len(singlePointRunesStack) == 0 {
-			return 'x'
-		}
-		next := singlePointRunesStack[index]
-		index++
-		return next
-	}
-
-	fmt.Printf("## Padding \n\n")
-
-	fmt.Printf("| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
-	fmt.Printf("|-------|-------------|-------------------|\n")
-
-	for i, original := range paddingRunes {
-		if !checkRune(original) {
-			replacement := getReplacement(true, i)
-			name := getName(replacement)
+// commands are the subcommands, in the order the usage lists them
+var commands = []struct {
+	name    string
+	summary string
+	run     func(args []string)
+}{
+	{"generate", "build the v2 alphabet and write emojis.txt, padding.txt and the tables", runGenerate},
+	{"validate", "check an alphabet for duplicates, non emojis and rendering problems", runValidate},
+	{"diff", "show which slots differ between two alphabets", runDiff},
+	{"encode", "encode bytes as ecoji", runEncode},
+	{"decode", "decode ecoji back to bytes", runDecode},
+	{"transcode", "move ecoji text from one alphabet to another", runTranscode},
+	{"ids", "encode UUIDs and digests as fixed length ecoji, or check they keep their order", runIDs},
+	{"registry", "list, verify or add named alphabet versions", runRegistry},
+	{"spec", "write the specification of an alphabet as markdown or json", runSpec},
+	{"names", "look up emoji names", runNames},
+	{"cache", "list or clear the emoji name cache", runCache},
+	{"review", "step through the replacements and record overrides", runReview},
+	{"serve", "browse the plan and try the codec in a browser", runServe},
+	{"flags", "count regional indicator flags in encoded text", runFlags},
+	{"stats", "compare alphabets by group, version, confusable emojis and size", runStats},
+}
 
-			fmt.Fprintf(os.Stderr, "replacement padding emoji (%c), using %x ( %c )  %s\n", original, replacement, replacement, name)
-			fmt.Printf("| %d | %c (%x) | %c (%x) (%s) |\n", i, original, original, replacement, replacement, name)
-		} else {
-			fmt.Printf("| %d | %c (%x) | - |\n", i, original, original)
-		}
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: ecojifixer <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run ecojifixer <command> -help for the flags of each command.")
+	fmt.Fprintln(w, "exit status is 0 on success, 1 on failure and 2 for bad usage.")
+}
 
-	fmt.Printf("\n## Emojis \n\n")
-
-	fmt.Printf("| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
-	fmt.Printf("|-------|-------------|-------------------|\n")
-
-	var finalSet []rune
-	for i, original := range ecojiset {
-		if !checkRune(original) {
-			replacement := getReplacement(false, i)
-			finalSet = append(finalSet, replacement)
-			name := getName(replacement)
-			fmt.Fprintf(os.Stderr, "replacemed emoji %d (%c), with %x ( %c )  %s\n", i, original, replacement, replacement, name)
-			fmt.Printf("| %d | %c (%x) | %c (%x) (%s) |\n", i, original, original, replacement, replacement, name)
-		} else {
-			finalSet = append(finalSet, original)
-			fmt.Printf("| %d | %c (%x) | - |\n", i, original, original)
+func main() {
+	// no command, or straight into flags, is generate like it always was
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") && !isHelp(os.Args[1]) {
+		runGenerate(os.Args[1:])
+		return
+	}
+	if isHelp(os.Args[1]) || os.Args[1] == "help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			c.run(os.Args[2:])
+			return
 		}
 	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+	usage(os.Stderr)
+	os.Exit(2)
+}
 
-	fmt.Printf("\n## Unused/remaining \n\n")
-
-	fmt.Printf("| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
-	fmt.Printf("|-------|-------------|-------------------|\n")
-
-	for i := index; i < len(singlePointRunesStack); i++ {
-		name := getName(singlePointRunesStack[i])
-		fmt.Printf("| - | %c (%x) (%s) | - |\n", singlePointRunesStack[i], singlePointRunesStack[i], name)
-	}
-	fmt.Fprintln(os.Stderr, "unused:", len(singlePointRunesStack)-index+1)
-
-	fmt.Fprintln(os.Stderr, "writing final set")
-	var str string
-	for _, r := range finalSet {
-		str = fmt.Sprintf("%s%x\n", str, r)
-
-	}
-
-	if err := os.WriteFile("emojis.txt", []byte(str), 0644); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+func isHelp(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help":
+		return true
 	}
-	// defer f.Close()
-
+	return false
 }
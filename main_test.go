@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"unicode/utf8"
 )
 
 const mappingFile = `package ecoji
@@ -1098,3 +1100,32 @@ func TestParseMapping(t *testing.T) {
 		fmt.Println("rune", string(r))
 	}
 }
+
+func TestParseMappingMalformed(t *testing.T) {
+	for _, buf := range []string{"", "not a mapping", "\temojis[0] = 0xFFFFFFFFFF\n", "\temojis[0] = 0xD800\n"} {
+		if _, err := parseMapping([]byte(buf)); !errors.Is(err, ErrMappingMalformed) {
+			t.Fatalf("%q: expected ErrMappingMalformed, got %v", buf, err)
+		}
+	}
+}
+
+func FuzzParseMapping(f *testing.F) {
+	f.Add([]byte(mappingFile))
+	if buf, err := getMapping(defaultMappingFile); err == nil {
+		f.Add(buf)
+	}
+	f.Add([]byte("\temojis[0] = 0x1F004\n"))
+	f.Add([]byte("\temojis[0] = 0xFFFFFFFFFF\n"))
+	f.Add([]byte("\temojis[1] = 0xD800\n"))
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		runes, err := parseMapping(buf)
+		if err != nil {
+			return
+		}
+		for _, r := range runes {
+			if !utf8.ValidRune(r) {
+				t.Fatalf("parsed an invalid rune %x", r)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeMarkdown writes the padding, emoji and unused tables for a plan
+func writeMarkdown(w io.Writer, p plan, name func(rune) string) {
+	score := func(s slot) string {
+		if !p.Scored {
+			return ""
+		}
+		return fmt.Sprintf(" (score %.2f)", s.Score)
+	}
+
+	fmt.Fprintf(w, "## Padding \n\n")
+
+	fmt.Fprintf(w, "| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
+	fmt.Fprintf(w, "|-------|-------------|-------------------|\n")
+
+	for _, s := range p.Padding {
+		if s.replaced() {
+			fmt.Fprintf(w, "| %d | %c (%x) | %c (%x) (%s)%s |\n", s.Index, s.Original, s.Original, s.Replacement, s.Replacement, name(s.Replacement), score(s))
+		} else if s.Sequence != nil {
+			fmt.Fprintf(w, "| %d | %c (%x) | %s (%s) (kept as a sequence) |\n", s.Index, s.Original, s.Original, string(s.Sequence), symbolHex(s.Sequence))
+		} else {
+			fmt.Fprintf(w, "| %d | %c (%x) | - |\n", s.Index, s.Original, s.Original)
+		}
+	}
+
+	fmt.Fprintf(w, "\n## Emojis \n\n")
+
+	fmt.Fprintf(w, "| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
+	fmt.Fprintf(w, "|-------|-------------|-------------------|\n")
+
+	for _, s := range p.Emojis {
+		if s.replaced() {
+			fmt.Fprintf(w, "| %d | %c (%x) | %c (%x) (%s)%s |\n", s.Index, s.Original, s.Original, s.Replacement, s.Replacement, name(s.Replacement), score(s))
+		} else if s.Sequence != nil {
+			fmt.Fprintf(w, "| %d | %c (%x) | %s (%s) (kept as a sequence) |\n", s.Index, s.Original, s.Original, string(s.Sequence), symbolHex(s.Sequence))
+		} else {
+			fmt.Fprintf(w, "| %d | %c (%x) | - |\n", s.Index, s.Original, s.Original)
+		}
+	}
+
+	st := p.stats()
+	fmt.Fprintf(w, "\nv1 slots kept as a sequence: %d of the %d that could be\n", st.Retained, st.Retainable)
+
+	fmt.Fprintf(w, "\n## Unused/remaining \n\n")
+
+	fmt.Fprintf(w, "| index | V1 Emoji (hex) | Replacement (hex) (name) |\n")
+	fmt.Fprintf(w, "|-------|-------------|-------------------|\n")
+
+	for _, r := range p.Unused {
+		fmt.Fprintf(w, "| - | %c (%x) (%s) | - |\n", r, r, name(r))
+	}
+
+	if len(p.Confusables) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n## Confusable\n\n")
+
+	fmt.Fprintf(w, "| cluster | kept |\n")
+	fmt.Fprintf(w, "|---------|------|\n")
+
+	for _, c := range p.Confusables {
+		kept := "from the pool"
+		switch {
+		case c.InAlphabet:
+			kept = "already in the alphabet"
+		case c.ExcludedBy != "":
+			kept = "but excluded as " + c.ExcludedBy
+		}
+		fmt.Fprintf(w, "| %s | %c (%x) (%s) |\n", string(c.Members), c.Kept, c.Kept, kept)
+	}
+}
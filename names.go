@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultCacheDir = "cache"
+
+// unknownName is what we print instead of a name we couldn't look up
+const unknownName = "unknown name"
+
+var (
+	// ErrNameNotFound means the provider has no name for the emoji
+	ErrNameNotFound = errors.New("name not found")
+	// ErrCacheCorrupt means a cached name couldn't be read back
+	ErrCacheCorrupt = errors.New("name cache corrupt")
+)
+
+// emojipediaURL is where emojipediaNames fetches pages from, %c is the emoji
+var emojipediaURL = "https://emojipedia.org/emoji/%c/"
+
+// nameLookup finds the name of one emoji
+type nameLookup func(rune) (string, error)
+
+func cachePath(dir string, r rune) string {
+	return filepath.Join(dir, fmt.Sprintf("%x", r))
+}
+
+func getCachedName(dir string, r rune) (string, bool, error) {
+	buf, err := os.ReadFile(cachePath(dir, r))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("%w: %v", ErrCacheCorrupt, err)
+	}
+	if len(buf) == 0 || !utf8.Valid(buf) || bytes.ContainsAny(buf, "\n<>") {
+		return "", false, fmt.Errorf("%w: %s doesn't hold a name", ErrCacheCorrupt, cachePath(dir, r))
+	}
+	return string(buf), true, nil
+}
+
+func saveNameToCache(dir string, r rune, name string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath(dir, r), []byte(name), 0644)
+}
+
+// emojipediaNames looks names up on emojipedia, caching them in dir. If the
+// name can't be cached it's still returned, along with the error.
+func emojipediaNames(dir string) nameLookup {
+	title := regexp.MustCompile(`<title>(.*)</title>`)
+	return func(r rune) (string, error) {
+		cached, found, err := getCachedName(dir, r)
+		if err != nil {
+			return "", fmt.Errorf("%x: %w", r, err)
+		}
+		if found {
+			return cached, nil
+		}
+		resp, err := http.Get(fmt.Sprintf(emojipediaURL, r))
+		if err != nil {
+			return "", fmt.Errorf("looking up %x on emojipedia: %w", r, err)
+		}
+		defer resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return "", fmt.Errorf("%x: %w on emojipedia", r, ErrNameNotFound)
+		case resp.StatusCode != http.StatusOK:
+			return "", fmt.Errorf("looking up %x on emojipedia: %s", r, resp.Status)
+		}
+		buf, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("looking up %x on emojipedia: %w", r, err)
+		}
+		match := title.FindSubmatch(buf)
+		if match == nil {
+			return "", fmt.Errorf("%x: %w, emojipedia page has no title", r, ErrNameNotFound)
+		}
+		name := strings.TrimSpace(strings.Replace(strings.Replace(string(match[1]), string(r), "", 1), "Emoji", "", 1))
+		if name == "" {
+			return "", fmt.Errorf("%x: %w, emojipedia page title is empty", r, ErrNameNotFound)
+		}
+		if err := saveNameToCache(dir, r, name); err != nil {
+			return name, fmt.Errorf("caching name for %x: %w", r, err)
+		}
+		return name, nil
+	}
+}
+
+// memoNames remembers every name, or error, so we only look each one up once
+// per run
+func memoNames(lookup nameLookup) nameLookup {
+	type result struct {
+		name string
+		err  error
+	}
+	seen := make(map[rune]result)
+	return func(r rune) (string, error) {
+		if res, ok := seen[r]; ok {
+			return res.name, res.err
+		}
+		name, err := lookup(r)
+		seen[r] = result{name, err}
+		return name, err
+	}
+}
+
+// lenientNames never fails, emojis we can't name are unknownName. Each error
+// is written to log once.
+func lenientNames(lookup nameLookup, log io.Writer) func(rune) string {
+	lookup = memoNames(lookup)
+	warned := make(map[rune]bool)
+	return func(r rune) string {
+		name, err := lookup(r)
+		if err != nil && !warned[r] {
+			warned[r] = true
+			fmt.Fprintln(log, "warning:", err)
+		}
+		if name == "" {
+			return unknownName
+		}
+		return name
+	}
+}
+
+// resolveNames looks up every rune up front, so a run either fails before
+// writing anything or has a name for everything
+func resolveNames(lookup nameLookup, runes []rune) (func(rune) string, error) {
+	names := make(map[rune]string)
+	for _, r := range runes {
+		if _, ok := names[r]; ok {
+			continue
+		}
+		name, err := lookup(r)
+		if err != nil {
+			return nil, err
+		}
+		names[r] = name
+	}
+	return func(r rune) string {
+		if name, ok := names[r]; ok {
+			return name
+		}
+		return unknownName
+	}, nil
+}
+
+// nameProviders are the ways we can name an emoji, by -names flag value
+var nameProviders = map[string]func(cacheDir string) nameLookup{
+	"emojipedia": emojipediaNames,
+	"offline":    func(string) nameLookup { return offlineName },
+}
+
+func nameProviderNames() string {
+	var names []string
+	for name := range nameProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func newNameProvider(name, cacheDir string) (nameLookup, error) {
+	provider, ok := nameProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown name provider %q, use one of %s", name, nameProviderNames())
+	}
+	return memoNames(provider(cacheDir)), nil
+}
+
+// parseRuneArg accepts either a hex code point like 1f9fc or the emoji itself
+func parseRuneArg(arg string) (rune, error) {
+	if runes := []rune(arg); len(runes) == 1 && runes[0] > 0x7f {
+		return runes[0], nil
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(strings.ToLower(arg), "0x"), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a hex code point or a single emoji", arg)
+	}
+	return rune(n), nil
+}
+
+func runNames(args []string) {
+	flags := flag.NewFlagSet("names", flag.ExitOnError)
+	provider := flags.String("names", "emojipedia", "where names come from: "+nameProviderNames())
+	cacheDir := flags.String("cache", defaultCacheDir, "directory emojipedia names are cached in")
+	unknown := flags.Bool("unknown-names", false, "print \""+unknownName+"\" and carry on when a name can't be looked up")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer names [flags] [rune...]")
+		fmt.Fprintln(flags.Output(), "prints the name of each rune, given as hex or the emoji itself, or of every rune in emojis.txt")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	lookup, err := newNameProvider(*provider, *cacheDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var runes []rune
+	for _, arg := range flags.Args() {
+		r, err := parseRuneArg(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		runes = append(runes, r)
+	}
+	if len(runes) == 0 {
+		if runes, err = readRuneFile("emojis.txt"); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	name := lenientNames(lookup, os.Stderr)
+	for _, r := range runes {
+		if !*unknown {
+			if _, err := lookup(r); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("%x\t%c\t%s\n", r, r, name(r))
+	}
+}
+
+// cachedRunes lists what's in the name cache, sorted by code point
+func cachedRunes(dir string) ([]rune, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var runes []rune
+	for _, e := range entries {
+		n, err := strconv.ParseInt(e.Name(), 16, 32)
+		if e.IsDir() || err != nil {
+			continue
+		}
+		runes = append(runes, rune(n))
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes, nil
+}
+
+func runCache(args []string) {
+	flags := flag.NewFlagSet("cache", flag.ExitOnError)
+	cacheDir := flags.String("cache", defaultCacheDir, "directory emojipedia names are cached in")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer cache [flags] list|clear")
+		fmt.Fprintln(flags.Output(), "lists or removes the cached emojipedia names")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	switch flags.Arg(0) {
+	case "list":
+		runes, err := cachedRunes(*cacheDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var corrupt int
+		for _, r := range runes {
+			name, _, err := getCachedName(*cacheDir, r)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				corrupt++
+				continue
+			}
+			fmt.Printf("%x\t%c\t%s\n", r, r, name)
+		}
+		if corrupt > 0 {
+			fmt.Fprintf(os.Stderr, "%d corrupt entries, ecojifixer cache clear removes them\n", corrupt)
+			os.Exit(1)
+		}
+	case "clear":
+		runes, err := cachedRunes(*cacheDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, r := range runes {
+			if err := os.Remove(cachePath(*cacheDir, r)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "removed %d cached names\n", len(runes))
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
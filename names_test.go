@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseRuneArg(t *testing.T) {
+	for arg, want := range map[string]rune{
+		"1f9fc":   0x1F9FC,
+		"0x1F9FC": 0x1F9FC,
+		"🧼":       0x1F9FC,
+		"2615":    0x2615,
+	} {
+		got, err := parseRuneArg(arg)
+		if err != nil || got != want {
+			t.Fatalf("%q should be %x, got %x %v", arg, want, got, err)
+		}
+	}
+	if _, err := parseRuneArg("soap"); err == nil {
+		t.Fatalf("soap isn't a rune")
+	}
+}
+
+func TestNameCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := saveNameToCache(dir, 0x1F9FC, "Soap"); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if err := saveNameToCache(dir, 0x2615, "Hot Beverage"); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	runes, err := cachedRunes(dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(runes) != 2 || runes[0] != 0x2615 {
+		t.Fatalf("unexpected cache %v", runes)
+	}
+	if name, found, err := getCachedName(dir, 0x1F9FC); !found || err != nil || name != "Soap" {
+		t.Fatalf("expected Soap, got %q %v %v", name, found, err)
+	}
+	if _, found, err := getCachedName(dir, 0x1F600); found || err != nil {
+		t.Fatalf("a missing name isn't an error, got %v %v", found, err)
+	}
+	os.WriteFile(cachePath(dir, 0x1F600), []byte("<html>"), 0644)
+	if _, _, err := getCachedName(dir, 0x1F600); !errors.Is(err, ErrCacheCorrupt) {
+		t.Fatalf("expected ErrCacheCorrupt, got %v", err)
+	}
+
+	lookup, err := newNameProvider("offline", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if got, err := lookup(0x1F9FC); err != nil || got != "soap" {
+		t.Fatalf("expected the emoji-test.txt name, got %q %v", got, err)
+	}
+	if _, err := lookup(0x41); !errors.Is(err, ErrNameNotFound) {
+		t.Fatalf("expected ErrNameNotFound, got %v", err)
+	}
+	if _, err := newNameProvider("wikipedia", dir); err == nil {
+		t.Fatalf("unknown providers should be an error")
+	}
+}
+
+func TestEmojipediaNames(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/emoji/\U0001F9FC/":
+			fmt.Fprint(w, "<html><title>\U0001F9FC Soap Emoji</title></html>")
+		case "/emoji/\U0001FAA3/":
+			fmt.Fprint(w, "<html>no title here</html>")
+		case "/emoji/\U0001F9FD/":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { emojipediaURL = url }(emojipediaURL)
+	emojipediaURL = srv.URL + "/emoji/%c/"
+
+	dir := t.TempDir()
+	lookup := emojipediaNames(dir)
+	if name, err := lookup(0x1F9FC); err != nil || name != "Soap" {
+		t.Fatalf("expected Soap, got %q %v", name, err)
+	}
+	if name, found, _ := getCachedName(dir, 0x1F9FC); !found || name != "Soap" {
+		t.Fatalf("Soap should be cached, got %q", name)
+	}
+	for r, want := range map[rune]error{0x1FAA3: ErrNameNotFound, 0x1F6DD: ErrNameNotFound} {
+		if _, err := lookup(r); !errors.Is(err, want) {
+			t.Fatalf("%x: expected %v, got %v", r, want, err)
+		}
+	}
+	if _, err := lookup(0x1F9FD); err == nil {
+		t.Fatalf("a server error should be an error")
+	}
+
+	// a flaky page doesn't stop a lenient run, but does stop a strict one
+	var log strings.Builder
+	name := lenientNames(lookup, &log)
+	if got := name(0x1F9FD); got != unknownName {
+		t.Fatalf("expected %q, got %q", unknownName, got)
+	}
+	name(0x1F9FD)
+	if strings.Count(log.String(), "warning:") != 1 {
+		t.Fatalf("expected one warning, got %q", log.String())
+	}
+	if _, err := resolveNames(lookup, []rune{0x1F9FC, 0x1F9FD}); err == nil {
+		t.Fatalf("resolveNames should fail on the flaky page")
+	}
+}
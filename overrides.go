@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const overridesFile = "overrides.txt"
+
+// overrides pins the replacement for a slot, keyed by index
+type overrides struct {
+	Emojis  map[int]rune
+	Padding map[int]rune
+}
+
+func newOverrides() overrides {
+	return overrides{
+		Emojis:  make(map[int]rune),
+		Padding: make(map[int]rune),
+	}
+}
+
+// builtinOverrides are the ones hardcoded in selectionOverrides and
+// paddingSelectionOverrides
+func builtinOverrides() overrides {
+	ov := newOverrides()
+	for i, emoji := range selectionOverrides {
+		ov.Emojis[i] = emoji[0]
+	}
+	for i, emoji := range paddingSelectionOverrides {
+		ov.Padding[i] = emoji[0]
+	}
+	return ov
+}
+
+// merge returns a copy of ov with everything in other on top
+func (ov overrides) merge(other overrides) overrides {
+	merged := newOverrides()
+	for _, src := range []overrides{ov, other} {
+		for i, r := range src.Emojis {
+			merged.Emojis[i] = r
+		}
+		for i, r := range src.Padding {
+			merged.Padding[i] = r
+		}
+	}
+	return merged
+}
+
+// parseOverrides reads lines like
+//
+//	emoji 859 1f972
+//	padding 1 1fab4
+//
+// blank lines and lines starting with # are ignored.
+func parseOverrides(r io.Reader) (overrides, error) {
+	ov := newOverrides()
+	scanner := bufio.NewScanner(r)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return ov, fmt.Errorf("overrides line %d: expected 3 fields, got %d", lineNo, len(fields))
+		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return ov, fmt.Errorf("overrides line %d: %w", lineNo, err)
+		}
+		n, err := strconv.ParseInt(fields[2], 16, 32)
+		if err != nil {
+			return ov, fmt.Errorf("overrides line %d: %w", lineNo, err)
+		}
+		switch fields[0] {
+		case "emoji":
+			ov.Emojis[index] = rune(n)
+		case "padding":
+			ov.Padding[index] = rune(n)
+		default:
+			return ov, fmt.Errorf("overrides line %d: unknown kind %q", lineNo, fields[0])
+		}
+	}
+	return ov, scanner.Err()
+}
+
+// readOverrides loads an overrides file, a missing file is just no overrides
+func readOverrides(path string) (overrides, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newOverrides(), nil
+		}
+		return overrides{}, err
+	}
+	return parseOverrides(bytes.NewReader(buf))
+}
+
+func formatOverrides(ov overrides) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# kind index replacement(hex)")
+	for _, kind := range []struct {
+		name string
+		m    map[int]rune
+	}{{"padding", ov.Padding}, {"emoji", ov.Emojis}} {
+		var indexes []int
+		for i := range kind.m {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			fmt.Fprintf(&buf, "%s %d %x\n", kind.name, i, kind.m[i])
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeOverrides(path string, ov overrides) error {
+	return os.WriteFile(path, formatOverrides(ov), 0644)
+}
+
+// checkOverrides makes sure every override makes sense before we build a
+// plan with it. Overrides for slots that don't need replacing are a warning,
+// two overrides picking the same rune or an override picking a rune the
+// alphabet already keeps somewhere else are errors.
+func checkOverrides(ecojiset []rune, ov overrides) validationReport {
+	var r validationReport
+
+	kept := make(map[rune]string)
+	for i, original := range paddingRunes {
+		if checkRune(original) {
+			kept[original] = fmt.Sprintf("padding %d", i)
+		}
+	}
+	for i, original := range ecojiset {
+		if checkRune(original) {
+			kept[original] = fmt.Sprintf("emoji %d", i)
+		}
+	}
+
+	picked := make(map[rune]string)
+	check := func(kind string, originals []rune, m map[int]rune) {
+		var indexes []int
+		for i := range m {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			c := m[i]
+			where := fmt.Sprintf("%s %d", kind, i)
+			switch {
+			case i < 0 || i >= len(originals):
+				r.add("override-unused", severityWarning, kind, i, "there is no %s, the override for %c (%x) does nothing", where, c, c)
+			case checkRune(originals[i]):
+				r.add("override-unused", severityWarning, kind, i, "%c (%x) doesn't need replacing, the override for %c (%x) does nothing",
+					originals[i], originals[i], c, c)
+			}
+			if other, ok := picked[c]; ok {
+				r.add("override-conflict", severityError, kind, i, "%c (%x) is also the override for %s", c, c, other)
+			} else {
+				picked[c] = where
+			}
+			if other, ok := kept[c]; ok && other != where {
+				r.add("override-shadowed", severityError, kind, i, "%c (%x) is already kept as %s", c, c, other)
+			}
+			if !checkRune(c) {
+				r.add("not-emoji", severityError, kind, i, "override %c (%x) is not a single code point emoji", c, c)
+			}
+		}
+	}
+	check("padding", paddingRunes, ov.Padding)
+	check("emoji", ecojiset, ov.Emojis)
+	return r
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robindiddams/emojidict"
+)
+
+func TestCheckOverrides(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	if report := checkOverrides(ecojiset, builtinOverrides()); len(report.Findings) != 0 {
+		t.Fatalf("builtin overrides should be fine, got %v", report.Findings)
+	}
+
+	ov := builtinOverrides()
+	ov.Emojis[0] = emojidict.Lotus[0]        // 1f004 doesn't need replacing
+	ov.Emojis[2] = ov.Emojis[859]            // same as another override
+	ov.Emojis[3] = ecojiset[1]               // already in the alphabet
+	ov.Padding[1] = emojidict.SmilingFace[0] // not a single code point emoji
+	ov.Emojis[5000] = emojidict.PlaygroundSlide[0]
+
+	want := map[string]int{
+		"override-unused":   2,
+		"override-conflict": 1,
+		"override-shadowed": 1,
+		"not-emoji":         1,
+	}
+	got := make(map[string]int)
+	report := checkOverrides(ecojiset, ov)
+	for _, f := range report.Findings {
+		got[f.Rule]++
+	}
+	for rule, n := range want {
+		if got[rule] != n {
+			t.Fatalf("expected %d %s, got %v", n, rule, report.Findings)
+		}
+	}
+	if report.ok() {
+		t.Fatalf("conflicts should be errors")
+	}
+}
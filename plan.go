@@ -0,0 +1,243 @@
+package main
+
+import (
+	"github.com/robindiddams/emojidict"
+)
+
+// slot is one position in the alphabet, either one of the padding runes or
+// one of the 1024 emojis. Replacement is 0 when the original is kept.
+// Score is how similar the replacement is to the original, when the plan
+// was built by similarity. Sequence is set when the original is kept as a
+// fully-qualified sequence like 2615 FE0F instead of being replaced.
+type slot struct {
+	Index       int
+	Original    rune
+	Replacement rune
+	Score       float64
+	Sequence    []rune
+}
+
+func (s slot) replaced() bool {
+	return s.Replacement != 0
+}
+
+// final is the rune that ends up in the v2 alphabet for this slot
+func (s slot) final() rune {
+	if s.replaced() {
+		return s.Replacement
+	}
+	return s.Original
+}
+
+// symbol is what ends up in the v2 alphabet for this slot, which can be a
+// sequence
+func (s slot) symbol() []rune {
+	if !s.replaced() && s.Sequence != nil {
+		return s.Sequence
+	}
+	return []rune{s.final()}
+}
+
+// plan is everything we decided about the v2 alphabet
+type plan struct {
+	Padding []slot
+	Emojis  []slot
+	Unused  []rune
+	Scored  bool
+	Relaxed []relaxedRune
+	// Confusables are the clusters we excluded look alikes from
+	Confusables []confusableCluster
+}
+
+// finalSymbols is finalSet with the sequences
+func (p plan) finalSymbols() [][]rune {
+	var set [][]rune
+	for _, s := range p.Emojis {
+		set = append(set, s.symbol())
+	}
+	return set
+}
+
+func (p plan) finalPaddingSymbols() [][]rune {
+	var set [][]rune
+	for _, s := range p.Padding {
+		set = append(set, s.symbol())
+	}
+	return set
+}
+
+func (p plan) finalSet() []rune {
+	var set []rune
+	for _, s := range p.Emojis {
+		set = append(set, s.final())
+	}
+	return set
+}
+
+// planStats are the headline numbers for a plan
+type planStats struct {
+	Emojis          int
+	EmojisReplaced  int
+	Padding         int
+	PaddingReplaced int
+	Unused          int
+	// Retained slots keep their v1 emoji as a sequence, Retainable is how
+	// many could
+	Retained   int
+	Retainable int
+}
+
+func (p plan) stats() planStats {
+	st := planStats{Emojis: len(p.Emojis), Padding: len(p.Padding), Unused: len(p.Unused)}
+	for _, s := range p.Emojis {
+		if s.replaced() {
+			st.EmojisReplaced++
+		}
+	}
+	for _, s := range p.Padding {
+		if s.replaced() {
+			st.PaddingReplaced++
+		}
+	}
+	for _, slots := range [][]slot{p.Padding, p.Emojis} {
+		for _, s := range slots {
+			if s.Sequence != nil && !s.replaced() {
+				st.Retained++
+			}
+			if !checkRune(s.Original) && sequenceFor(s.Original) != nil {
+				st.Retainable++
+			}
+		}
+	}
+	return st
+}
+
+func (p plan) finalPadding() []rune {
+	var set []rune
+	for _, s := range p.Padding {
+		set = append(set, s.final())
+	}
+	return set
+}
+
+// checkRune reports whether r is still a single code point emoji
+func checkRune(r rune) bool {
+	for _, emoji := range emojidict.All {
+		if len(emoji) == 1 && emoji[0] == r {
+			return true
+		}
+	}
+	return false
+}
+
+// planOptions are the knobs buildPlan leaves at their defaults
+type planOptions struct {
+	Exclusions []exclusion
+	// Sequences keeps v1 runes that are only emojis with U+FE0F as that
+	// sequence instead of replacing them
+	Sequences bool
+}
+
+func defaultPlanOptions() planOptions {
+	return planOptions{Exclusions: exclusions}
+}
+
+// buildPlan walks the padding and then the v1 set, replacing every rune that
+// isn't a single code point emoji anymore with an override, or whatever the
+// selector picks from the pool. If the pool runs out it returns a
+// *poolExhaustedError along with the partial plan.
+func buildPlan(ecojiset []rune, ov overrides, sel Selector) (plan, error) {
+	return buildPlanWith(ecojiset, ov, sel, defaultPlanOptions())
+}
+
+func buildPlanWith(ecojiset []rune, ov overrides, sel Selector, opts planOptions) (plan, error) {
+	pool, removed := candidatePool(ecojiset, ov, opts.Exclusions)
+
+	var p plan
+	for i, original := range paddingRunes {
+		p.Padding = append(p.Padding, slot{Index: i, Original: original})
+	}
+	for i, original := range ecojiset {
+		p.Emojis = append(p.Emojis, slot{Index: i, Original: original})
+	}
+
+	// pointers to every slot the selector gets to fill
+	var open []*slot
+	fill := func(slots []slot, overridden map[int]rune) {
+		for i := range slots {
+			s := &slots[i]
+			if checkRune(s.Original) {
+				continue
+			}
+			if override, ok := overridden[s.Index]; ok {
+				s.Replacement = override
+				continue
+			}
+			if opts.Sequences {
+				if seq := sequenceFor(s.Original); seq != nil {
+					s.Sequence = seq
+					continue
+				}
+			}
+			open = append(open, s)
+		}
+	}
+	fill(p.Padding, ov.Padding)
+	fill(p.Emojis, ov.Emojis)
+
+	sel.Select(p, open, pool)
+
+	used := make(map[rune]bool)
+	var unfilled int
+	for _, s := range open {
+		if s.Replacement == 0 {
+			unfilled++
+			continue
+		}
+		used[s.Replacement] = true
+	}
+	for _, r := range pool {
+		if !used[r] {
+			p.Unused = append(p.Unused, r)
+		}
+	}
+
+	// a stable plan is scored when whatever fills its changed slots is
+	scoredBy := sel
+	if stable, ok := sel.(stableSelector); ok {
+		scoredBy = stable.next
+	}
+	_, p.Scored = scoredBy.(similaritySelector)
+	for _, slots := range [][]slot{p.Padding, p.Emojis} {
+		for i := range slots {
+			if slots[i].replaced() {
+				slots[i].Score = similarity(slots[i].Original, slots[i].Replacement)
+			}
+		}
+	}
+	if unfilled > 0 {
+		return p, &poolExhaustedError{Unfilled: unfilled, Removed: removed}
+	}
+	return p, nil
+}
+
+// loadPlan reads the v1 mapping and the overrides file and builds the plan
+func loadPlan(mappingPath, overridesPath string, sel Selector) (plan, error) {
+	buf, err := getMapping(mappingPath)
+	if err != nil {
+		return plan{}, err
+	}
+	ecojiset, err := parseMapping(buf)
+	if err != nil {
+		return plan{}, err
+	}
+	fileOverrides, err := readOverrides(overridesPath)
+	if err != nil {
+		return plan{}, err
+	}
+	ov := builtinOverrides().merge(fileOverrides)
+	if err := checkOverrides(ecojiset, ov).err(); err != nil {
+		return plan{}, err
+	}
+	return buildPlan(ecojiset, ov, sel)
+}
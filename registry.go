@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// registryFile is the manifest of named alphabets
+const registryFile = "alphabets.json"
+
+const (
+	statusDraft = "draft"
+	statusFinal = "final"
+)
+
+var (
+	// ErrChecksum means a registered alphabet doesn't match its checksum
+	ErrChecksum = errors.New("alphabet checksum mismatch")
+	// ErrDraftAlphabet means we were about to encode with an alphabet that
+	// isn't final
+	ErrDraftAlphabet = errors.New("alphabet is a draft")
+)
+
+// registeredAlphabet is one named version of the alphabet. Symbols are hex
+// code points like in emojis.txt, space separated for sequences.
+type registeredAlphabet struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Created  string   `json:"created"`
+	Parent   string   `json:"parent,omitempty"`
+	Checksum string   `json:"checksum"`
+	Emojis   []string `json:"emojis"`
+	Padding  []string `json:"padding"`
+}
+
+type registry struct {
+	Alphabets []registeredAlphabet `json:"alphabets"`
+}
+
+// alphabetChecksum is the sha256 of the emojis and then the padding, in the
+// emojis.txt format, so cat emojis.txt padding.txt | sha256sum gives the
+// same thing
+func alphabetChecksum(emojis, padding [][]rune) string {
+	h := sha256.New()
+	h.Write(formatSymbolFile(emojis))
+	h.Write(formatSymbolFile(padding))
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+func parseSymbols(hexSymbols []string) ([][]rune, error) {
+	var symbols [][]rune
+	for _, s := range hexSymbols {
+		var sym []rune
+		for _, field := range strings.Fields(s) {
+			r, err := parseCodePoint(field)
+			if err != nil {
+				return nil, err
+			}
+			sym = append(sym, r)
+		}
+		symbols = append(symbols, sym)
+	}
+	return symbols, nil
+}
+
+// symbols parses the alphabet and checks it against its checksum
+func (a registeredAlphabet) symbols() (emojis, padding [][]rune, err error) {
+	if emojis, err = parseSymbols(a.Emojis); err != nil {
+		return nil, nil, fmt.Errorf("alphabet %q: %w", a.Name, err)
+	}
+	if padding, err = parseSymbols(a.Padding); err != nil {
+		return nil, nil, fmt.Errorf("alphabet %q: %w", a.Name, err)
+	}
+	if sum := alphabetChecksum(emojis, padding); sum != a.Checksum {
+		return nil, nil, fmt.Errorf("%w: %q is %s, registered as %s", ErrChecksum, a.Name, sum, a.Checksum)
+	}
+	return emojis, padding, nil
+}
+
+func newRegisteredAlphabet(name, status, parent string, emojis, padding [][]rune) registeredAlphabet {
+	a := registeredAlphabet{
+		Name:     name,
+		Status:   status,
+		Created:  time.Now().UTC().Format("2006-01-02"),
+		Parent:   parent,
+		Checksum: alphabetChecksum(emojis, padding),
+	}
+	for _, sym := range emojis {
+		a.Emojis = append(a.Emojis, symbolHex(sym))
+	}
+	for _, sym := range padding {
+		a.Padding = append(a.Padding, symbolHex(sym))
+	}
+	return a
+}
+
+// readRegistry reads the manifest, a missing one is an empty registry
+func readRegistry(path string) (registry, error) {
+	var r registry
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(buf, &r); err != nil {
+		return r, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func writeRegistry(path string, r registry) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+func (r registry) lookup(name string) (registeredAlphabet, bool) {
+	for _, a := range r.Alphabets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return registeredAlphabet{}, false
+}
+
+// add registers a new alphabet, names can't be reused and the parent has to
+// be registered already
+func (r *registry) add(a registeredAlphabet) error {
+	if _, ok := r.lookup(a.Name); ok {
+		return fmt.Errorf("alphabet %q is already registered", a.Name)
+	}
+	if _, ok := r.lookup(a.Parent); a.Parent != "" && !ok {
+		return fmt.Errorf("parent %q isn't registered", a.Parent)
+	}
+	if a.Status != statusDraft && a.Status != statusFinal {
+		return fmt.Errorf("status has to be %s or %s, not %q", statusDraft, statusFinal, a.Status)
+	}
+	r.Alphabets = append(r.Alphabets, a)
+	return nil
+}
+
+// newestFinal is the final alphabet created last, the later one in the
+// registry if two were created the same day. v1 is what we're replacing, so
+// it never counts.
+func (r registry) newestFinal() (registeredAlphabet, bool) {
+	var newest registeredAlphabet
+	var ok bool
+	for _, a := range r.Alphabets {
+		if a.Status == statusFinal && a.Name != "v1" && a.Created >= newest.Created {
+			newest, ok = a, true
+		}
+	}
+	return newest, ok
+}
+
+// defaultAlphabet is what the commands use when no alphabet is given, the
+// newest final one in the registry. Until one replaces v1 there's no default.
+func defaultAlphabet() (string, error) {
+	r, err := readRegistry(registryFile)
+	if err != nil {
+		return "", err
+	}
+	a, ok := r.newestFinal()
+	if !ok {
+		return "", fmt.Errorf("%w: there's no final alphabet to replace v1 in %s yet, pass -alphabet", ErrDraftAlphabet, registryFile)
+	}
+	return a.Name, nil
+}
+
+// mustResolveAlphabet turns an empty alphabet flag into defaultAlphabet
+func mustResolveAlphabet(name string) string {
+	if name != "" {
+		return name
+	}
+	name, err := defaultAlphabet()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return name
+}
+
+// checkFinal makes sure name is a final registered alphabet, anything else,
+// including v2 and files that aren't registered, is a draft
+func checkFinal(name string) error {
+	r, err := readRegistry(registryFile)
+	if err != nil {
+		return err
+	}
+	if a, ok := r.lookup(name); ok && a.Status == statusFinal {
+		return nil
+	}
+	return fmt.Errorf("%w: %q isn't a final alphabet in %s", ErrDraftAlphabet, name, registryFile)
+}
+
+// mustLoadEncoder is mustLoadEncoding for encoding, which needs a final
+// alphabet unless drafts are allowed, an empty name is defaultAlphabet
+func mustLoadEncoder(name string, allowDraft bool) *ecoji.Encoding {
+	name = mustResolveAlphabet(name)
+	if !allowDraft {
+		if err := checkFinal(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, "pass -draft to encode with it anyway")
+			os.Exit(2)
+		}
+	}
+	return mustLoadEncoding(name)
+}
+
+func runRegistry(args []string) {
+	flags := flag.NewFlagSet("registry", flag.ExitOnError)
+	path := flags.String("registry", registryFile, "the manifest of named alphabets")
+	status := flags.String("status", statusDraft, "status of an added alphabet, draft or final")
+	parent := flags.String("parent", "", "the version an added alphabet was made from")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer registry [flags] list|verify|add <name> <alphabet>")
+		fmt.Fprintln(flags.Output(), "lists, checks the checksums of, or adds named alphabet versions")
+		fmt.Fprintln(flags.Output(), "an added alphabet is v1, v2, a generated emojis file or markdown tables")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	r, err := readRegistry(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	switch {
+	case flags.NArg() == 1 && flags.Arg(0) == "list":
+		for _, a := range r.Alphabets {
+			parent := a.Parent
+			if parent == "" {
+				parent = "-"
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", a.Name, a.Status, a.Created, parent, a.Checksum)
+		}
+	case flags.NArg() == 1 && flags.Arg(0) == "verify":
+		var failed int
+		for _, a := range r.Alphabets {
+			emojis, padding, err := a.symbols()
+			if err == nil {
+				_, err = newSymbolAlphabet(emojis, padding)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		fmt.Printf("%d alphabets ok\n", len(r.Alphabets))
+	case flags.NArg() == 3 && flags.Arg(0) == "add":
+		enc, err := loadEncoding(flags.Arg(2))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var emojis, padding [][]rune
+		symbols := enc.Symbols()
+		for _, s := range symbols.Emojis {
+			emojis = append(emojis, []rune(s))
+		}
+		for _, s := range symbols.Padding {
+			padding = append(padding, []rune(s))
+		}
+		if err := r.add(newRegisteredAlphabet(flags.Arg(1), *status, *parent, emojis, padding)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writeRegistry(*path, r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r, err := readRegistry(registryFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for _, a := range r.Alphabets {
+		if _, _, err := a.symbols(); err != nil {
+			t.Fatalf("error %v", err)
+		}
+	}
+
+	// the checksum is the same as hashing the files
+	emojis, err := os.ReadFile("emojis.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	padding, err := os.ReadFile(paddingFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	draft, ok := r.lookup("v2-draft")
+	if !ok {
+		t.Fatalf("v2-draft should be registered")
+	}
+	if want := fmt.Sprintf("sha256:%x", sha256.Sum256(append(emojis, padding...))); draft.Checksum != want {
+		t.Fatalf("checksum should be %s, got %s", want, draft.Checksum)
+	}
+	byName, err := loadEncoding("v2-draft")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if byName.Symbols() != v2.Symbols() {
+		t.Fatalf("v2-draft should be the alphabet in emojis.txt")
+	}
+
+	if err := checkFinal("v1"); err != nil {
+		t.Fatalf("v1 is final, got %v", err)
+	}
+	for _, name := range []string{"v2-draft", "v2", "emojis.txt"} {
+		if err := checkFinal(name); !errors.Is(err, ErrDraftAlphabet) {
+			t.Fatalf("%s should be a draft, got %v", name, err)
+		}
+	}
+
+	tampered := draft
+	tampered.Emojis = append([]string{}, draft.Emojis...)
+	tampered.Emojis[0], tampered.Emojis[1] = tampered.Emojis[1], tampered.Emojis[0]
+	if _, _, err := tampered.symbols(); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("swapping two emojis should be %v, got %v", ErrChecksum, err)
+	}
+
+	for _, tc := range []struct {
+		name, status, parent string
+	}{
+		{"v1", statusFinal, ""},
+		{"v2-final", statusFinal, "v2-nope"},
+		{"v2-final", "approved", "v2-draft"},
+	} {
+		a := draft
+		a.Name, a.Status, a.Parent = tc.name, tc.status, tc.parent
+		if err := r.add(a); err == nil {
+			t.Fatalf("adding %+v should fail", tc)
+		}
+	}
+	final := draft
+	final.Name, final.Status, final.Parent = "v2-final", statusFinal, "v2-draft"
+	if err := r.add(final); err != nil {
+		t.Fatalf("error %v", err)
+	}
+}
+
+// withFinalV2 runs the test from a directory whose registry has v1 and a
+// final copy of the v2 in emojis.txt, the way it will once v2 is released
+func withFinalV2(t *testing.T) {
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v1, err := readRegistry(registryFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var emojis, padding [][]rune
+	for _, s := range v2.Symbols().Emojis {
+		emojis = append(emojis, []rune(s))
+	}
+	for _, s := range v2.Symbols().Padding {
+		padding = append(padding, []rune(s))
+	}
+	r := registry{Alphabets: []registeredAlphabet{v1.Alphabets[0]}}
+	if err := r.add(newRegisteredAlphabet("v2-final", statusFinal, "v1", emojis, padding)); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	dir := t.TempDir()
+	if err := writeRegistry(filepath.Join(dir, registryFile), r); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// TestDefaultAlphabet checks v1 is never the default, and runs encode and
+// decode with their default flags once there's a final alphabet to pick
+func TestDefaultAlphabet(t *testing.T) {
+	if name, err := defaultAlphabet(); !errors.Is(err, ErrDraftAlphabet) {
+		t.Fatalf("with only v1 final there should be no default, got %q %v", name, err)
+	}
+	r := registry{Alphabets: []registeredAlphabet{
+		{Name: "a", Status: statusFinal, Created: "2026-01-01"},
+		{Name: "b", Status: statusFinal, Created: "2026-03-01"},
+		{Name: "c", Status: statusDraft, Created: "2026-04-01"},
+		{Name: "d", Status: statusFinal, Created: "2026-03-01"},
+		{Name: "e", Status: statusFinal, Created: "2026-02-01"},
+		{Name: "v1", Status: statusFinal, Created: "2026-05-01"},
+	}}
+	if a, ok := r.newestFinal(); !ok || a.Name != "d" {
+		t.Fatalf("newest final should be d, got %q", a.Name)
+	}
+	if _, ok := (registry{}).newestFinal(); ok {
+		t.Fatalf("an empty registry has no final alphabet")
+	}
+
+	withFinalV2(t)
+	dir := t.TempDir()
+	in, encoded, decoded := dir+"/in", dir+"/encoded", dir+"/decoded"
+	if err := os.WriteFile(in, []byte("hi"), 0644); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	runEncode([]string{"-o", encoded, in})
+	runDecode([]string{"-o", decoded, encoded})
+	buf, err := os.ReadFile(encoded)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v2, err := loadEncoding("v2-final")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if want := v2.Encode([]byte("hi")) + "\n"; string(buf) != want {
+		t.Fatalf("encode should use v2-final by default, got %q want %q", buf, want)
+	}
+	if buf, err = os.ReadFile(decoded); err != nil || string(buf) != "hi" {
+		t.Fatalf("decode should round trip, got %q %v", buf, err)
+	}
+}
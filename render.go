@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/rivo/uniseg"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// hasEmojiPresentation is true for code points that show up as an emoji on
+// their own. We don't embed emoji-data.txt, so this approximates the
+// Emoji_Presentation property with the single code point fully-qualified
+// entries of emoji-test.txt.
+func hasEmojiPresentation(r rune) bool {
+	info, ok := lookupEmoji(r)
+	return ok && len(info.CodePoints) == 1 && info.Status == "fully-qualified"
+}
+
+// checkRendering makes sure every rune in the alphabet renders as its own
+// wide glyph. It flags runes that are narrow (East_Asian_Width) or text by
+// default (no Emoji_Presentation), and runes that would join a neighbour into
+// one grapheme cluster, like two regional indicators or an emoji followed by
+// a skin tone, checking every rune against each kind of neighbour that could
+// join it. Then it encodes samples random inputs to check every rune of the
+// output is its own cluster, which is only a sample.
+func checkRendering(emojis, padding []rune, samples int, seed int64) validationReport {
+	return checkSymbolRendering(symbolsOf(emojis), symbolsOf(padding), samples, seed)
+}
+
+// checkSymbolRendering is checkRendering for alphabets with sequences, where
+// a sequence has to be a single cluster and fully-qualified to count as an
+// emoji presentation
+func checkSymbolRendering(emojis, padding [][]rune, samples int, seed int64) validationReport {
+	var r validationReport
+
+	type entry struct {
+		kind  string
+		index int
+		sym   []rune
+	}
+	var all []entry
+	for i, c := range padding {
+		all = append(all, entry{"padding", i, c})
+	}
+	for i, c := range emojis {
+		all = append(all, entry{"emoji", i, c})
+	}
+
+	for _, e := range all {
+		if w := uniseg.StringWidth(string(e.sym)); w != 2 {
+			r.add("render-width", severityError, e.kind, e.index, "%s (%s) renders %d columns wide", string(e.sym), symbolHex(e.sym), w)
+		}
+		if n := uniseg.GraphemeClusterCount(string(e.sym)); n != 1 {
+			r.add("grapheme-cluster", severityError, e.kind, e.index, "%s (%s) is %d grapheme clusters", string(e.sym), symbolHex(e.sym), n)
+		}
+		if len(e.sym) == 1 && !hasEmojiPresentation(e.sym[0]) || len(e.sym) > 1 && !checkSymbol(e.sym) {
+			r.add("text-presentation", severityError, e.kind, e.index, "%s (%s) is text by default (Emoji_Presentation approximated from emoji-test.txt)",
+				string(e.sym), symbolHex(e.sym))
+		}
+	}
+
+	// check each symbol once against an emoji that joins nothing, which
+	// catches combining marks and joiners on either side. Anything else that
+	// joins depends on what's next to it, so each symbol is also checked
+	// against one symbol of the alphabet from every neighbour class it could
+	// join: regional indicators, skin tones, joiners and keycaps.
+	probe := string(rune(0x1F600))
+	before, after := make(map[string]entry), make(map[string]entry)
+	for _, a := range all {
+		if c := neighbourClass(a.sym[len(a.sym)-1]); c != "" {
+			if _, ok := before[c]; !ok {
+				before[c] = a
+			}
+		}
+		if c := neighbourClass(a.sym[0]); c != "" {
+			if _, ok := after[c]; !ok {
+				after[c] = a
+			}
+		}
+	}
+	classes := []string{"regional indicator", "skin tone", "joiner", "keycap base", "keycap"}
+	for _, e := range all {
+		if uniseg.GraphemeClusterCount(probe+string(e.sym)) != 2 {
+			r.add("grapheme-cluster", severityError, e.kind, e.index, "%s (%s) joins onto whatever comes before it",
+				string(e.sym), symbolHex(e.sym))
+			continue
+		}
+		if uniseg.GraphemeClusterCount(string(e.sym)+probe) != 2 {
+			r.add("grapheme-cluster", severityError, e.kind, e.index, "%s (%s) takes whatever comes after it into its cluster",
+				string(e.sym), symbolHex(e.sym))
+			continue
+		}
+		for _, c := range classes {
+			if b, ok := before[c]; ok && uniseg.GraphemeClusterCount(string(b.sym)+string(e.sym)) != 2 {
+				r.add("grapheme-cluster", severityError, e.kind, e.index, "%s (%s) joins onto the %s %s when it follows it",
+					string(e.sym), symbolHex(e.sym), c, symbolHex(b.sym))
+				break
+			}
+			if a, ok := after[c]; ok && uniseg.GraphemeClusterCount(string(e.sym)+string(a.sym)) != 2 {
+				r.add("grapheme-cluster", severityError, e.kind, e.index, "%s (%s) takes the %s %s into its cluster when it comes before it",
+					string(e.sym), symbolHex(e.sym), c, symbolHex(a.sym))
+				break
+			}
+		}
+	}
+	if len(all) == 0 {
+		return r
+	}
+
+	// encoding is only sampled, so a clean run here isn't proof
+	rnd := rand.New(rand.NewSource(seed))
+	alphabet, err := newSymbolAlphabet(emojis, padding)
+	if err != nil {
+		r.add("segmentation", severityError, "", -1, "can't simulate encoding: %v", err)
+		return r
+	}
+	enc, err := ecoji.NewSymbolEncoding(alphabet)
+	if err != nil {
+		r.add("segmentation", severityError, "", -1, "can't simulate encoding: %v", err)
+		return r
+	}
+	var failed int
+	var example string
+	for i := 0; i < samples; i++ {
+		buf := make([]byte, 1+rnd.Intn(64))
+		rnd.Read(buf)
+		encoded := enc.Encode(buf)
+		// every 5 bytes, or part of 5, is 4 symbols
+		if uniseg.GraphemeClusterCount(encoded) != (len(buf)+4)/5*4 {
+			if failed == 0 {
+				example = encoded
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		r.add("segmentation", severityError, "", -1, "%d of %d sampled random encodings had runes sharing a grapheme cluster, e.g. %s",
+			failed, samples, example)
+	}
+	return r
+}
+
+// neighbourClass is the kind of rune that can join an alphabet symbol next to
+// it into one grapheme cluster, or "" for runes that only join combining marks
+func neighbourClass(r rune) string {
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return "regional indicator"
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		return "skin tone"
+	case r == 0x200D:
+		return "joiner"
+	case r >= '0' && r <= '9', r == '#', r == '*':
+		return "keycap base"
+	case r == 0x20E3 || r == 0xFE0F:
+		return "keycap"
+	}
+	return ""
+}
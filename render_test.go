@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckRendering(t *testing.T) {
+	emojis, err := readRuneFile("emojis.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	padding, err := readRuneFile(paddingFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if report := checkRendering(emojis, padding, 200, 1); len(report.Findings) != 0 {
+		t.Fatalf("emojis.txt should render fine, got %v", report.Findings)
+	}
+
+	v1, err := readRuneFile("emojisv1.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	joins := make(map[rune]bool)
+	var segmentation bool
+	for _, f := range checkRendering(v1, paddingRunes, 200, 1).Findings {
+		switch f.Rule {
+		case "grapheme-cluster":
+			joins[v1[f.Index]] = true
+		case "segmentation":
+			segmentation = true
+		}
+	}
+	// regional indicators join each other, skin tones join anything
+	for _, r := range []rune{0x1F1E6, 0x1F1FF, 0x1F3FB, 0x1F3FF} {
+		if !joins[r] {
+			t.Fatalf("%x should be flagged for joining", r)
+		}
+	}
+	if !segmentation {
+		t.Fatalf("random v1 encodings should hit a shared cluster")
+	}
+}
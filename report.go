@@ -0,0 +1,105 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+)
+
+//go:embed templates/report.html
+var reportTemplateSource string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"char": func(r rune) string { return string(r) },
+	"hex":  func(r rune) string { return fmt.Sprintf("%x", r) },
+}).Parse(reportTemplateSource))
+
+type reportRow struct {
+	Index       int
+	Original    rune
+	Replacement rune
+	Replaced    bool
+	Name        string
+	Subgroup    string
+	Scored      bool
+	Score       float64
+	Sequence    string
+	SequenceHex string
+}
+
+type reportGroup struct {
+	Subgroup string
+	Rows     []reportRow
+}
+
+type reportData struct {
+	Stats    planStats
+	Warnings []finding
+	Padding  []reportRow
+	Emojis   []reportRow
+	Unused   []reportGroup
+}
+
+func newReportData(p plan, name func(rune) string) reportData {
+	toRows := func(slots []slot) []reportRow {
+		var rows []reportRow
+		for _, s := range slots {
+			row := reportRow{
+				Index:    s.Index,
+				Original: s.Original,
+				Subgroup: subgroupOf(s.final()),
+				Scored:   p.Scored,
+				Score:    s.Score,
+			}
+			if s.replaced() {
+				row.Replaced = true
+				row.Replacement = s.Replacement
+				row.Name = name(s.Replacement)
+			} else if s.Sequence != nil {
+				row.Sequence = string(s.Sequence)
+				row.SequenceHex = symbolHex(s.Sequence)
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+	data := reportData{
+		Stats:    p.stats(),
+		Warnings: p.validation().Findings,
+		Padding:  toRows(p.Padding),
+		Emojis:   toRows(p.Emojis),
+	}
+
+	bySubgroup := make(map[string][]reportRow)
+	for _, r := range p.Unused {
+		sub := subgroupOf(r)
+		bySubgroup[sub] = append(bySubgroup[sub], reportRow{Original: r, Name: name(r), Subgroup: sub})
+	}
+	for sub, rows := range bySubgroup {
+		data.Unused = append(data.Unused, reportGroup{Subgroup: sub, Rows: rows})
+	}
+	sort.Slice(data.Unused, func(i, j int) bool {
+		return data.Unused[i].Subgroup < data.Unused[j].Subgroup
+	})
+	return data
+}
+
+// writeReport writes a self contained html version of the plan
+func writeReport(w io.Writer, p plan, name func(rune) string) error {
+	return reportTemplate.Execute(w, newReportData(p, name))
+}
+
+func writeReportFile(path string, p plan, name func(rune) string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, p, name); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	p, err := loadPlan(defaultMappingFile, "", sequentialSelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var b strings.Builder
+	if err := writeReport(&b, p, func(r rune) string { return "name-" + string(r) }); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	html := b.String()
+	for _, want := range []string{"<h2>Padding</h2>", "<h2>Emojis</h2>", "<h2>Unused/remaining</h2>", "name-\U0001FAB4"} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("report should contain %q", want)
+		}
+	}
+	if got := strings.Count(html, `<tr class="replaced">`); got != p.stats().EmojisReplaced+p.stats().PaddingReplaced {
+		t.Fatalf("report should have a row per replacement, got %d", got)
+	}
+}
+
+func TestReportSequences(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	opts := defaultPlanOptions()
+	opts.Sequences = true
+	p, err := buildPlanWith(ecojiset, builtinOverrides(), sequentialSelector{}, opts)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	st := p.stats()
+	if st.Retained == 0 || st.Retainable < st.Retained {
+		t.Fatalf("expected some retained slots, got %+v", st)
+	}
+	var b strings.Builder
+	if err := writeReport(&b, p, func(rune) string { return "" }); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if got := strings.Count(b.String(), "(kept as a sequence)"); got != st.Retained {
+		t.Fatalf("expected %d kept sequences in the report, got %d", st.Retained, got)
+	}
+
+	b.Reset()
+	writeMarkdown(&b, p, func(rune) string { return "" })
+	if want := fmt.Sprintf("v1 slots kept as a sequence: %d of the %d that could be", st.Retained, st.Retainable); !strings.Contains(b.String(), want) {
+		t.Fatalf("the markdown should say %q", want)
+	}
+}
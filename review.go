@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// how many alternatives from the pool we offer for each slot
+const reviewLookahead = 5
+
+type reviewItem struct {
+	padding bool
+	slot    slot
+}
+
+// reviewModel walks through every slot that needs a replacement and records
+// what the reviewer picked. It only knows about keys, so it can be driven by
+// a line at a time from a terminal or by a script.
+type reviewModel struct {
+	items    []reviewItem
+	cursor   int
+	choices  overrides
+	existing overrides
+	pool     []rune
+	name     func(rune) string
+	quit     bool
+}
+
+func newReviewModel(p plan, existing overrides, name func(rune) string) *reviewModel {
+	m := &reviewModel{
+		choices:  newOverrides().merge(existing),
+		existing: newOverrides().merge(existing),
+		pool:     p.Unused,
+		name:     name,
+	}
+	for _, s := range p.Padding {
+		if s.replaced() {
+			m.items = append(m.items, reviewItem{padding: true, slot: s})
+		}
+	}
+	for _, s := range p.Emojis {
+		if s.replaced() {
+			m.items = append(m.items, reviewItem{slot: s})
+		}
+	}
+	return m
+}
+
+func (m *reviewModel) done() bool {
+	return m.quit || m.cursor >= len(m.items)
+}
+
+func (m *reviewModel) choicesFor(item reviewItem) map[int]rune {
+	if item.padding {
+		return m.choices.Padding
+	}
+	return m.choices.Emojis
+}
+
+// skip forgets whatever was picked for the current slot since the review
+// started, going back to the override it had before, if any
+func (m *reviewModel) skip(item reviewItem) {
+	existing := m.existing.Emojis
+	if item.padding {
+		existing = m.existing.Padding
+	}
+	if r, ok := existing[item.slot.Index]; ok {
+		m.choicesFor(item)[item.slot.Index] = r
+		return
+	}
+	delete(m.choicesFor(item), item.slot.Index)
+}
+
+// taken reports whether r was already picked for some slot other than the
+// current one
+func (m *reviewModel) taken(r rune) bool {
+	current := m.items[m.cursor]
+	for _, item := range []struct {
+		padding bool
+		chosen  map[int]rune
+	}{{false, m.choices.Emojis}, {true, m.choices.Padding}} {
+		for i, c := range item.chosen {
+			if c == r && !(item.padding == current.padding && i == current.slot.Index) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// alternatives are the next few runes from the pool nobody has picked yet
+func (m *reviewModel) alternatives() []rune {
+	var alts []rune
+	for _, r := range m.pool {
+		if len(alts) == reviewLookahead {
+			break
+		}
+		if !m.taken(r) {
+			alts = append(alts, r)
+		}
+	}
+	return alts
+}
+
+// Update applies one key:
+//
+//	a or enter  accept the proposed replacement
+//	s           skip, leave this slot to the generator, or the override it
+//	            had before the review
+//	1-5         pick that alternative from the pool
+//	b           go back one slot
+//	q           stop reviewing
+func (m *reviewModel) Update(key string) {
+	if m.done() {
+		return
+	}
+	item := m.items[m.cursor]
+	switch key {
+	case "", "a":
+		m.choicesFor(item)[item.slot.Index] = item.slot.Replacement
+		m.cursor++
+	case "s":
+		m.skip(item)
+		m.cursor++
+	case "b":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "q":
+		m.quit = true
+	default:
+		n, err := strconv.Atoi(key)
+		alts := m.alternatives()
+		if err != nil || n < 1 || n > len(alts) {
+			return
+		}
+		m.choicesFor(item)[item.slot.Index] = alts[n-1]
+		m.cursor++
+	}
+}
+
+func (m *reviewModel) View() string {
+	if m.done() {
+		return fmt.Sprintf("reviewed %d of %d slots\n", m.cursor, len(m.items))
+	}
+	item := m.items[m.cursor]
+	kind := "emoji"
+	if item.padding {
+		kind = "padding"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%d/%d] %s %d\n", m.cursor+1, len(m.items), kind, item.slot.Index)
+	fmt.Fprintf(&b, "  v1:       %c (%x)\n", item.slot.Original, item.slot.Original)
+	fmt.Fprintf(&b, "  proposed: %c (%x) (%s)\n", item.slot.Replacement, item.slot.Replacement, m.name(item.slot.Replacement))
+	if chosen, ok := m.choicesFor(item)[item.slot.Index]; ok {
+		fmt.Fprintf(&b, "  chosen:   %c (%x) (%s)\n", chosen, chosen, m.name(chosen))
+	}
+	alts := m.alternatives()
+	for i, alt := range alts {
+		fmt.Fprintf(&b, "  %d) %c (%x) (%s)\n", i+1, alt, alt, m.name(alt))
+	}
+	b.WriteString("[a]ccept [s]kip ")
+	switch len(alts) {
+	case 0:
+	case 1:
+		b.WriteString("[1] pick ")
+	default:
+		fmt.Fprintf(&b, "[1-%d] pick ", len(alts))
+	}
+	b.WriteString("[b]ack [q]uit > ")
+	return b.String()
+}
+
+// runReviewLoop feeds one key per input line into the model until it's done
+func runReviewLoop(m *reviewModel, in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, m.View())
+	for !m.done() && scanner.Scan() {
+		m.Update(strings.TrimSpace(scanner.Text()))
+		fmt.Fprint(out, m.View())
+	}
+}
+
+func runReview(args []string) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	in := fs.String("overrides", overridesFile, "overrides file to start from, missing is fine")
+	out := fs.String("o", "", "where to write the overrides, the -overrides file by default")
+	mappingPath := fs.String("mapping", defaultMappingFile, "v1 mapping from keith-turner/ecoji")
+	fs.Parse(args)
+	if *out == "" {
+		*out = *in
+	}
+
+	existing, err := readOverrides(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	p, err := loadPlan(*mappingPath, *in, sequentialSelector{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	m := newReviewModel(p, existing, lenientNames(emojipediaNames(defaultCacheDir), os.Stderr))
+	runReviewLoop(m, os.Stdin, os.Stdout)
+
+	if err := writeOverrides(*out, m.choices); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "wrote", *out)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReviewModel(t *testing.T) {
+	p := plan{
+		Padding: []slot{
+			{Index: 0, Original: 0x2615},
+			{Index: 1, Original: 0x269C, Replacement: 0x1FAB4},
+		},
+		Emojis: []slot{
+			{Index: 0, Original: 0x1F004},
+			{Index: 1, Original: 0x1F170, Replacement: 0x1F9BE},
+			{Index: 2, Original: 0x1F171, Replacement: 0x1F9BF},
+			{Index: 3, Original: 0x1F17E, Replacement: 0x1F9BB},
+		},
+		Unused: []rune{0x1F6DD, 0x1F6DE, 0x1F6DF},
+	}
+	m := newReviewModel(p, newOverrides(), func(rune) string { return "" })
+
+	// accept the padding, pick the 2nd alternative, skip, go back and skip
+	// again, then pick the 1st alternative for the last one
+	in := strings.NewReader("a\n2\ns\nb\ns\n1\n")
+	var out strings.Builder
+	runReviewLoop(m, in, &out)
+
+	if !m.done() {
+		t.Fatalf("should have reviewed every slot")
+	}
+	// only 3 runes in the pool, so only 3 to pick from
+	if !strings.Contains(out.String(), "[1-3] pick") || strings.Contains(out.String(), "[1-5]") {
+		t.Fatalf("the prompt should offer as many picks as there are alternatives, got %q", out.String())
+	}
+	if got := m.choices.Padding[1]; got != 0x1FAB4 {
+		t.Fatalf("padding 1 should be accepted, got %x", got)
+	}
+	if got := m.choices.Emojis[1]; got != 0x1F6DE {
+		t.Fatalf("emoji 1 should be the 2nd alternative, got %x", got)
+	}
+	if got := m.choices.Emojis[3]; got != 0x1F6DD {
+		t.Fatalf("emoji 3 should be the 1st alternative, got %x", got)
+	}
+	if _, ok := m.choices.Emojis[2]; ok {
+		t.Fatalf("emoji 2 was skipped and shouldn't be overridden")
+	}
+
+	ov, err := parseOverrides(strings.NewReader(string(formatOverrides(m.choices))))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if ov.Emojis[1] != 0x1F6DE || ov.Padding[1] != 0x1FAB4 || len(ov.Emojis) != 2 {
+		t.Fatalf("overrides didn't round trip: %v", ov)
+	}
+}
+
+// TestReviewSkipAfterBack picks something, goes back and skips, the pick
+// shouldn't stick, and an override from before the review should come back
+func TestReviewSkipAfterBack(t *testing.T) {
+	p := plan{
+		Emojis: []slot{
+			{Index: 1, Original: 0x1F170, Replacement: 0x1F9BE},
+			{Index: 2, Original: 0x1F171, Replacement: 0x1F9BF},
+			{Index: 3, Original: 0x1F17E, Replacement: 0x1F9BB},
+		},
+		Unused: []rune{0x1F6DD, 0x1F6DE},
+	}
+	existing := newOverrides()
+	existing.Emojis[2] = 0x1F9BF
+	m := newReviewModel(p, existing, func(rune) string { return "" })
+	for _, key := range []string{"1", "b", "s", "2", "b", "s"} {
+		m.Update(key)
+	}
+	if _, ok := m.choices.Emojis[1]; ok {
+		t.Fatalf("emoji 1 was skipped after going back, got %x", m.choices.Emojis[1])
+	}
+	if got := m.choices.Emojis[2]; got != 0x1F9BF {
+		t.Fatalf("emoji 2 should keep its existing override, got %x", got)
+	}
+}
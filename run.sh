@@ -1,3 +1,3 @@
 #!/bin/bash
 
-go run main.go >suggested.md
\ No newline at end of file
+go run . generate -markdown suggested.md
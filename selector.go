@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Selector picks the replacement for every open slot, open being the slots
+// that need replacing and don't have an override. It sets Replacement on
+// each slot to a rune from pool, using each rune at most once, and leaves it
+// 0 if it couldn't find one.
+type Selector interface {
+	Select(p plan, open []*slot, pool []rune)
+}
+
+// selectors are the strategies you can pick with -strategy
+var selectors = map[string]func(seed int64) Selector{
+	"sequential": func(int64) Selector { return sequentialSelector{} },
+	"nearest":    func(int64) Selector { return nearestSelector{} },
+	"sort":       func(int64) Selector { return sortPreservingSelector{} },
+	"random":     func(seed int64) Selector { return randomSelector{seed: seed} },
+	"similarity": func(int64) Selector { return similaritySelector{} },
+	"compact":    func(int64) Selector { return compactSelector{} },
+}
+
+func selectorNames() string {
+	var names []string
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func newSelector(name string, seed int64) (Selector, error) {
+	newSel, ok := selectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, pick one of %s", name, selectorNames())
+	}
+	return newSel(seed), nil
+}
+
+// sequentialSelector hands out the pool in emojidict order, which is how
+// the alphabet has always been built
+type sequentialSelector struct{}
+
+func (sequentialSelector) Select(p plan, open []*slot, pool []rune) {
+	for i, s := range open {
+		if i == len(pool) {
+			return
+		}
+		s.Replacement = pool[i]
+	}
+}
+
+// compactSelector hands out the candidates that take the fewest UTF-8
+// bytes first, otherwise in emojidict order. For emojis that's also the
+// fewest UTF-16 units, below U+FFFF is 3 bytes or 1 unit and above is 4
+// bytes or 2 units. The padding slots are open first so they get the
+// shortest ones.
+type compactSelector struct{}
+
+func (compactSelector) Select(p plan, open []*slot, pool []rune) {
+	sorted := append([]rune{}, pool...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return utf8.RuneLen(sorted[i]) < utf8.RuneLen(sorted[j])
+	})
+	sequentialSelector{}.Select(p, open, sorted)
+}
+
+// nearestSelector picks the unused candidate with the closest code point
+type nearestSelector struct{}
+
+func (nearestSelector) Select(p plan, open []*slot, pool []rune) {
+	used := make(map[rune]bool)
+	for _, s := range open {
+		var best rune
+		for _, r := range pool {
+			if used[r] {
+				continue
+			}
+			if best == 0 || distance(s.Original, r) < distance(s.Original, best) {
+				best = r
+			}
+		}
+		if best == 0 {
+			return
+		}
+		s.Replacement = best
+		used[best] = true
+	}
+}
+
+func distance(a, b rune) rune {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// randomSelector shuffles the pool with a fixed seed and then hands it out
+// in order, so the same seed always gives the same alphabet
+type randomSelector struct {
+	seed int64
+}
+
+func (s randomSelector) Select(p plan, open []*slot, pool []rune) {
+	shuffled := make([]rune, len(pool))
+	for i, j := range rand.New(rand.NewSource(s.seed)).Perm(len(pool)) {
+		shuffled[i] = pool[j]
+	}
+	sequentialSelector{}.Select(p, open, shuffled)
+}
+
+// sortedSlots is every slot in the order the encoded runes have to sort in:
+// padding, padding40, emojis 0-255, padding41, emojis 256-511, padding42,
+// emojis 512-767, padding43, emojis 768-1023
+func sortedSlots(p plan) []*slot {
+	var slots []*slot
+	if len(p.Padding) != 5 || len(p.Emojis) != 1024 {
+		return slots
+	}
+	slots = append(slots, &p.Padding[0], &p.Padding[1])
+	for quarter := 0; quarter < 4; quarter++ {
+		if quarter > 0 {
+			slots = append(slots, &p.Padding[quarter+1])
+		}
+		for i := quarter * 256; i < (quarter+1)*256; i++ {
+			slots = append(slots, &p.Emojis[i])
+		}
+	}
+	return slots
+}
+
+// sortPreservingSelector tries to keep the alphabet sorted, so that encoded
+// data sorts the same as the input. The v1 emojis we keep can't move, so
+// for every open slot it counts how many kept runes a candidate would sort
+// out of place with, and picks the set of candidates that upsets the order
+// the least. Those then go into the open slots smallest first.
+type sortPreservingSelector struct{}
+
+func (sortPreservingSelector) Select(p plan, open []*slot, pool []rune) {
+	isOpen := make(map[*slot]bool)
+	for _, s := range open {
+		isOpen[s] = true
+	}
+
+	// position is how many kept slots come before an open one
+	var kept []rune
+	var slots []*slot
+	position := make(map[*slot]int)
+	for _, s := range sortedSlots(p) {
+		if isOpen[s] {
+			position[s] = len(kept)
+			slots = append(slots, s)
+		} else {
+			kept = append(kept, s.final())
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i] < kept[j] })
+	rank := func(r rune) int {
+		return sort.Search(len(kept), func(i int) bool { return kept[i] >= r })
+	}
+
+	if len(slots) > len(pool) {
+		slots = slots[:len(pool)]
+	}
+	cost := make([][]float64, len(slots))
+	for i, s := range slots {
+		cost[i] = make([]float64, len(pool))
+		for j, candidate := range pool {
+			cost[i][j] = math.Abs(float64(rank(candidate) - position[s]))
+		}
+	}
+	var chosen []rune
+	for _, j := range hungarian(cost) {
+		chosen = append(chosen, pool[j])
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i] < chosen[j] })
+	for i, s := range slots {
+		s.Replacement = chosen[i]
+	}
+}
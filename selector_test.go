@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testEcojiset(t *testing.T) []rune {
+	buf, err := getMapping(defaultMappingFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	ecojiset, err := parseMapping(buf)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	return ecojiset
+}
+
+// outOfOrder counts the slots that sort before the slot in front of them
+func outOfOrder(p plan) int {
+	var n int
+	order := sortedSlots(p)
+	for i := 1; i < len(order); i++ {
+		if order[i].final() <= order[i-1].final() {
+			n++
+		}
+	}
+	return n
+}
+
+// pairsInOrder counts the pairs of slots that sort the way their indexes
+// do, which is what decides whether encoded data keeps its order
+func pairsInOrder(p plan) int {
+	var n int
+	order := sortedSlots(p)
+	for i := range order {
+		for j := i + 1; j < len(order); j++ {
+			if order[i].final() < order[j].final() {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestSelectors(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	plans := make(map[string]plan)
+	for name := range selectors {
+		sel, err := newSelector(name, 42)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		p, err := buildPlan(ecojiset, builtinOverrides(), sel)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if validation := p.validation(); !validation.ok() {
+			t.Fatalf("%s plan has problems: %v", name, validation.errors())
+		}
+		for i, override := range selectionOverrides {
+			if p.Emojis[i].Replacement != override[0] {
+				t.Fatalf("%s should keep the override for %d", name, i)
+			}
+		}
+		plans[name] = p
+	}
+
+	// sequential is what emojis.txt was generated with
+	want, err := os.ReadFile("emojis.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var got strings.Builder
+	for _, r := range plans["sequential"].finalSet() {
+		fmt.Fprintf(&got, "%x\n", r)
+	}
+	if got.String() != string(want) {
+		t.Fatalf("sequential plan should match emojis.txt")
+	}
+
+	again, err := buildPlan(ecojiset, builtinOverrides(), randomSelector{seed: 42})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for i := range again.Emojis {
+		if again.Emojis[i].final() != plans["random"].Emojis[i].final() {
+			t.Fatalf("random with the same seed should give the same plan")
+		}
+	}
+
+	if outOfOrder(plans["sort"]) >= outOfOrder(plans["sequential"]) {
+		t.Fatalf("sort should have fewer slots out of order than sequential, %d vs %d", outOfOrder(plans["sort"]), outOfOrder(plans["sequential"]))
+	}
+	if sorted, sequential := pairsInOrder(plans["sort"]), pairsInOrder(plans["sequential"]); sorted < sequential {
+		t.Fatalf("sort should keep at least as many pairs of slots in order as sequential, %d vs %d", sorted, sequential)
+	}
+
+	// compact should spend fewer bytes than sequential, every candidate
+	// below U+FFFF goes in before any above it
+	size := func(name string) float64 {
+		_, v2, err := planEncodings(plans[name])
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		return expectedSize(v2.Symbols(), 5, utf8Size)
+	}
+	if size("compact") >= size("sequential") {
+		t.Fatalf("compact should encode smaller than sequential, %v vs %v", size("compact"), size("sequential"))
+	}
+
+	if _, err := newSelector("nope", 0); err == nil {
+		t.Fatalf("unknown strategy should be an error")
+	}
+}
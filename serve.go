@@ -0,0 +1,129 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+//go:embed web
+var webAssets embed.FS
+
+type gridSlot struct {
+	Index    int    `json:"index"`
+	V1       string `json:"v1"`
+	V1Hex    string `json:"v1Hex"`
+	V2       string `json:"v2"`
+	V2Hex    string `json:"v2Hex"`
+	Replaced bool   `json:"replaced"`
+}
+
+type grid struct {
+	Padding []gridSlot `json:"padding"`
+	Emojis  []gridSlot `json:"emojis"`
+}
+
+func newGrid(p plan) grid {
+	toGrid := func(slots []slot) []gridSlot {
+		var out []gridSlot
+		for _, s := range slots {
+			out = append(out, gridSlot{
+				Index:    s.Index,
+				V1:       string(s.Original),
+				V1Hex:    fmt.Sprintf("%x", s.Original),
+				V2:       string(s.symbol()),
+				V2Hex:    symbolHex(s.symbol()),
+				Replaced: s.replaced(),
+			})
+		}
+		return out
+	}
+	return grid{Padding: toGrid(p.Padding), Emojis: toGrid(p.Emojis)}
+}
+
+// newServeHandler serves the static page, the grid and encode/decode for
+// both alphabets
+func newServeHandler(p plan) (http.Handler, error) {
+	v1, v2, err := planEncodings(p)
+	if err != nil {
+		return nil, err
+	}
+	encodings := map[string]*ecoji.Encoding{"v1": v1, "v2": v2}
+
+	static, err := fs.Sub(webAssets, "web")
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/api/grid", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newGrid(p))
+	})
+	// POST /api/codec?op=encode|decode&alphabet=v1|v2 with the input as the body
+	mux.HandleFunc("/api/codec", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		enc, ok := encodings[r.URL.Query().Get("alphabet")]
+		if !ok {
+			http.Error(w, "alphabet must be v1 or v2", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		switch r.URL.Query().Get("op") {
+		case "encode":
+			io.WriteString(w, enc.Encode(body))
+		case "decode":
+			decoded, err := enc.Decode(string(body))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			w.Write(decoded)
+		default:
+			http.Error(w, "op must be encode or decode", http.StatusBadRequest)
+		}
+	})
+	return mux, nil
+}
+
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := flags.Int("port", 8080, "port to listen on (localhost only)")
+	mappingPath := flags.String("mapping", defaultMappingFile, "v1 mapping from keith-turner/ecoji")
+	overridesPath := flags.String("overrides", overridesFile, "overrides file")
+	flags.Parse(args)
+
+	p, err := loadPlan(*mappingPath, *overridesPath, sequentialSelector{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	handler, err := newServeHandler(p)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	addr := net.JoinHostPort("localhost", fmt.Sprint(*port))
+	fmt.Fprintf(os.Stderr, "serving on http://%s\n", addr)
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
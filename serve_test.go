@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	p, err := loadPlan(defaultMappingFile, "", sequentialSelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	handler, err := newServeHandler(p)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/grid")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var g grid
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	resp.Body.Close()
+	if len(g.Emojis) != 1024 || len(g.Padding) != 5 {
+		t.Fatalf("grid should have 1024 emojis and 5 padding, got %d and %d", len(g.Emojis), len(g.Padding))
+	}
+
+	post := func(query, body string) string {
+		resp, err := http.Post(srv.URL+"/api/codec?"+query, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		defer resp.Body.Close()
+		buf, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: %s", query, buf)
+		}
+		return string(buf)
+	}
+	for _, alphabet := range []string{"v1", "v2"} {
+		encoded := post("op=encode&alphabet="+alphabet, "hello ecoji")
+		if decoded := post("op=decode&alphabet="+alphabet, encoded); decoded != "hello ecoji" {
+			t.Fatalf("%s round trip gave %q", alphabet, decoded)
+		}
+	}
+
+	resp, err = http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("index should be served, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// words that don't say anything about what an emoji looks like
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true,
+	"with": true, "in": true, "on": true, "at": true, "for": true,
+}
+
+func keywords(name string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// similarity scores how close candidate is to original, from 0 to 1. Same
+// subgroup counts the most, then same group, shared words in the name and
+// finally how close the code points are.
+func similarity(original, candidate rune) float64 {
+	var score float64
+	a, aok := lookupEmoji(original)
+	b, bok := lookupEmoji(candidate)
+	if aok && bok {
+		if a.Group == b.Group {
+			score += 0.2
+			if a.Subgroup == b.Subgroup {
+				score += 0.4
+			}
+		}
+		aw, bw := keywords(a.Name), keywords(b.Name)
+		var shared int
+		for w := range aw {
+			if bw[w] {
+				shared++
+			}
+		}
+		if union := len(aw) + len(bw) - shared; union > 0 {
+			score += 0.3 * float64(shared) / float64(union)
+		}
+	}
+	distance := math.Abs(float64(original - candidate))
+	score += 0.1 * math.Max(0, 1-distance/4096)
+	return score
+}
+
+// hungarian solves the assignment problem for a cost matrix with no more
+// rows than columns, returning the column picked for each row so that the
+// total cost is as small as possible.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+	inf := math.Inf(1)
+
+	// this is the usual potentials version, everything is 1 indexed so that
+	// column 0 can stand in for "no column yet"
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = inf
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], inf, 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
+
+// similaritySelector matches every slot to the candidate most like the v1
+// emoji, over all the slots at once
+type similaritySelector struct{}
+
+func (similaritySelector) Select(p plan, open []*slot, pool []rune) {
+	// if we don't have enough candidates the last slots don't get one
+	matched := open
+	if len(matched) > len(pool) {
+		matched = matched[:len(pool)]
+	}
+	cost := make([][]float64, len(matched))
+	for i, s := range matched {
+		cost[i] = make([]float64, len(pool))
+		for j, candidate := range pool {
+			cost[i][j] = 1 - similarity(s.Original, candidate)
+		}
+	}
+	for i, j := range hungarian(cost) {
+		matched[i].Replacement = pool[j]
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHungarian(t *testing.T) {
+	cost := [][]float64{
+		{4, 1, 3, 9},
+		{2, 0, 5, 9},
+		{3, 2, 2, 9},
+	}
+	assignment := hungarian(cost)
+	var total float64
+	for i, j := range assignment {
+		total += cost[i][j]
+	}
+	if total != 5 {
+		t.Fatalf("best assignment costs 5, got %v (%v)", total, assignment)
+	}
+}
+
+func TestSimilarPlan(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	sequential, err := buildPlan(ecojiset, builtinOverrides(), sequentialSelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	similar, err := buildPlan(ecojiset, builtinOverrides(), similaritySelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if validation := similar.validation(); !validation.ok() {
+		t.Fatalf("similar plan has problems: %v", validation.errors())
+	}
+
+	total := func(p plan) float64 {
+		var sum float64
+		for _, s := range append(p.Padding, p.Emojis...) {
+			if s.replaced() {
+				sum += similarity(s.Original, s.Replacement)
+			}
+		}
+		return sum
+	}
+	if total(similar) <= total(sequential) {
+		t.Fatalf("similar plan should score better than sequential, %v vs %v", total(similar), total(sequential))
+	}
+	if len(similar.Unused) != len(sequential.Unused) {
+		t.Fatalf("both plans should use the same number of candidates")
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// specFormat is bumped when the layout of the spec changes, not the alphabet
+const specFormat = 1
+
+// bitLayout is how a group of 5 bytes becomes 4 emoji indexes
+var bitLayout = []string{
+	"emoji 0 = byte 0 << 2 | byte 1 >> 6",
+	"emoji 1 = (byte 1 & 0x3f) << 4 | byte 2 >> 4",
+	"emoji 2 = (byte 2 & 0x0f) << 6 | byte 3 >> 2",
+	"emoji 3 = (byte 3 & 0x03) << 8 | byte 4",
+}
+
+type specPadding struct {
+	Name       string   `json:"name"`
+	Symbol     string   `json:"symbol"`
+	CodePoints []string `json:"codePoints"`
+	UsedWhen   string   `json:"usedWhen"`
+}
+
+type specEmoji struct {
+	Index      int      `json:"index"`
+	Bits       string   `json:"bits"`
+	Symbol     string   `json:"symbol"`
+	CodePoints []string `json:"codePoints"`
+}
+
+type specInvariant struct {
+	Rule  string `json:"rule"`
+	Holds bool   `json:"holds"`
+}
+
+// spec is everything an implementer needs, mirroring the comments in
+// mapping.txt
+type spec struct {
+	Format          int             `json:"format"`
+	Name            string          `json:"name"`
+	Status          string          `json:"status"`
+	Created         string          `json:"created,omitempty"`
+	Parent          string          `json:"parent,omitempty"`
+	Checksum        string          `json:"checksum"`
+	BytesPerGroup   int             `json:"bytesPerGroup"`
+	SymbolsPerGroup int             `json:"symbolsPerGroup"`
+	BitsPerSymbol   int             `json:"bitsPerSymbol"`
+	BitLayout       []string        `json:"bitLayout"`
+	Padding         []specPadding   `json:"padding"`
+	SortInvariants  []specInvariant `json:"sortInvariants"`
+	Emojis          []specEmoji     `json:"emojis"`
+}
+
+func hexCodePoints(sym []rune) []string {
+	return strings.Fields(symbolHex(sym))
+}
+
+// newSpec builds the spec for an alphabet, with the version details from
+// the registry if it's registered there
+func newSpec(name string) (spec, error) {
+	enc, err := loadEncoding(name)
+	if err != nil {
+		return spec{}, err
+	}
+	symbols := enc.Symbols()
+	var emojis, padding [][]rune
+	for _, s := range symbols.Emojis {
+		emojis = append(emojis, []rune(s))
+	}
+	for _, s := range symbols.Padding {
+		padding = append(padding, []rune(s))
+	}
+
+	s := spec{
+		Format:          specFormat,
+		Name:            name,
+		Status:          "unregistered",
+		Checksum:        alphabetChecksum(emojis, padding),
+		BytesPerGroup:   5,
+		SymbolsPerGroup: 4,
+		BitsPerSymbol:   10,
+		BitLayout:       bitLayout,
+	}
+	reg, err := readRegistry(registryFile)
+	if err != nil {
+		return spec{}, err
+	}
+	if a, ok := reg.lookup(name); ok {
+		s.Status, s.Created, s.Parent = a.Status, a.Created, a.Parent
+	}
+
+	usedWhen := []string{
+		"fills out a last group of 1 to 3 bytes, once for each missing emoji",
+		"ends a last group of 4 bytes whose byte 3 & 0x03 is 0",
+		"ends a last group of 4 bytes whose byte 3 & 0x03 is 1",
+		"ends a last group of 4 bytes whose byte 3 & 0x03 is 2",
+		"ends a last group of 4 bytes whose byte 3 & 0x03 is 3",
+	}
+	for i, name := range []string{"padding", "padding40", "padding41", "padding42", "padding43"} {
+		s.Padding = append(s.Padding, specPadding{
+			Name:       name,
+			Symbol:     string(padding[i]),
+			CodePoints: hexCodePoints(padding[i]),
+			UsedWhen:   usedWhen[i],
+		})
+	}
+	for i, sym := range emojis {
+		s.Emojis = append(s.Emojis, specEmoji{
+			Index:      i,
+			Bits:       fmt.Sprintf("%010b", i),
+			Symbol:     string(sym),
+			CodePoints: hexCodePoints(sym),
+		})
+	}
+
+	// the same rules as mapping.txt, symbols compare as UTF-8 strings,
+	// which is code point order
+	e := symbols.Emojis
+	p := symbols.Padding
+	before := func(s string, others ...string) bool {
+		for _, o := range others {
+			if s >= o {
+				return false
+			}
+		}
+		return true
+	}
+	sorted := true
+	for i := 1; i < len(e); i++ {
+		if e[i-1] >= e[i] {
+			sorted = false
+		}
+	}
+	s.SortInvariants = []specInvariant{
+		{"padding sorts before everything", before(p[0], append(p[1:], e[:]...)...)},
+		{"padding40 sorts between padding and emojis[0]", p[0] < p[1] && p[1] < e[0]},
+		{"padding41 sorts between emojis[255] and emojis[256]", e[255] < p[2] && p[2] < e[256]},
+		{"padding42 sorts between emojis[511] and emojis[512]", e[511] < p[3] && p[3] < e[512]},
+		{"padding43 sorts between emojis[767] and emojis[768]", e[767] < p[4] && p[4] < e[768]},
+		{"emojis are in ascending order", sorted},
+	}
+	return s, nil
+}
+
+func (s spec) sortsLikeInput() bool {
+	for _, inv := range s.SortInvariants {
+		if !inv.Holds {
+			return false
+		}
+	}
+	return true
+}
+
+// readSpecAlphabet reads the alphabet back out of a json spec
+func readSpecAlphabet(path string) (emojis, padding [][]rune, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var s spec
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var hexEmojis, hexPadding []string
+	for _, e := range s.Emojis {
+		hexEmojis = append(hexEmojis, strings.Join(e.CodePoints, " "))
+	}
+	for _, p := range s.Padding {
+		hexPadding = append(hexPadding, strings.Join(p.CodePoints, " "))
+	}
+	if emojis, err = parseSymbols(hexEmojis); err == nil {
+		padding, err = parseSymbols(hexPadding)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return emojis, padding, nil
+}
+
+// writeSpecJSON leaves <, > and & alone, the bit layout is full of them
+func writeSpecJSON(w io.Writer, s spec) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func writeSpecMarkdown(w io.Writer, s spec) {
+	fmt.Fprintf(w, "# Ecoji alphabet %s\n\n", s.Name)
+
+	fmt.Fprintf(w, "| | |\n|--|--|\n")
+	fmt.Fprintf(w, "| spec format | %d |\n", s.Format)
+	fmt.Fprintf(w, "| status | %s |\n", s.Status)
+	if s.Created != "" {
+		fmt.Fprintf(w, "| created | %s |\n", s.Created)
+	}
+	if s.Parent != "" {
+		fmt.Fprintf(w, "| parent | %s |\n", s.Parent)
+	}
+	fmt.Fprintf(w, "| checksum | %s |\n", s.Checksum)
+
+	fmt.Fprintf(w, "\n## Encoding\n\n")
+	fmt.Fprintf(w, "Input is read %d bytes at a time. Each group is %d bits, split into %d values of %d bits, and each value is the index of an emoji:\n\n",
+		s.BytesPerGroup, s.BytesPerGroup*8, s.SymbolsPerGroup, s.BitsPerSymbol)
+	fmt.Fprintf(w, "```\n%s\n```\n\n", strings.Join(s.BitLayout, "\n"))
+	fmt.Fprintf(w, "The last group can be short, its missing bytes count as 0 and the emojis they'd fill are padding:\n\n")
+	fmt.Fprintf(w, "| input bytes | output |\n|-------------|--------|\n")
+	fmt.Fprintf(w, "| 1 | emoji 0, padding, padding, padding |\n")
+	fmt.Fprintf(w, "| 2 | emoji 0, emoji 1, padding, padding |\n")
+	fmt.Fprintf(w, "| 3 | emoji 0, emoji 1, emoji 2, padding |\n")
+	fmt.Fprintf(w, "| 4 | emoji 0, emoji 1, emoji 2, padding40 + (byte 3 & 0x03) |\n")
+	fmt.Fprintf(w, "\nSymbols are the code points listed, some are more than one. Decoders match symbols greedily, taking the longest one that matches, and since no symbol is a prefix of another that match is never ambiguous. ")
+	fmt.Fprintf(w, "Decoders skip line breaks (U+000A and U+000D) between symbols, anything else that isn't a symbol is an error, and so is padding anywhere but the end of the last group.\n")
+
+	fmt.Fprintf(w, "\n## Padding\n\n")
+	fmt.Fprintf(w, "| name | symbol | code points | used when |\n|------|--------|-------------|-----------|\n")
+	for _, p := range s.Padding {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", p.Name, p.Symbol, strings.Join(p.CodePoints, " "), p.UsedWhen)
+	}
+
+	fmt.Fprintf(w, "\n## Sort order\n\n")
+	if s.sortsLikeInput() {
+		fmt.Fprintf(w, "Every rule holds, so encoded strings sort the same as their input bytes.\n\n")
+	} else {
+		fmt.Fprintf(w, "Not every rule holds, so encoded strings don't always sort the same as their input bytes.\n\n")
+	}
+	fmt.Fprintf(w, "| rule | holds |\n|------|-------|\n")
+	for _, inv := range s.SortInvariants {
+		holds := "no"
+		if inv.Holds {
+			holds = "yes"
+		}
+		fmt.Fprintf(w, "| %s | %s |\n", inv.Rule, holds)
+	}
+
+	fmt.Fprintf(w, "\n## Emojis\n\n")
+	fmt.Fprintf(w, "| index | bits | symbol | code points |\n|-------|------|--------|-------------|\n")
+	for _, e := range s.Emojis {
+		fmt.Fprintf(w, "| %d | %s | %s | %s |\n", e.Index, e.Bits, e.Symbol, strings.Join(e.CodePoints, " "))
+	}
+}
+
+func runSpec(args []string) {
+	flags := flag.NewFlagSet("spec", flag.ExitOnError)
+	format := flags.String("format", "markdown", "markdown or json")
+	out := flags.String("o", "-", "output file, - for stdout")
+	draft := flags.Bool("draft", false, "allow a spec for an alphabet that isn't final in "+registryFile)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer spec [flags] [alphabet]")
+		fmt.Fprintln(flags.Output(), "writes the specification of an alphabet for implementers, by default the newest final one in "+registryFile+" that replaces v1")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	name := mustResolveAlphabet(flags.Arg(0))
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q, use markdown or json\n", *format)
+		os.Exit(2)
+	}
+	if !*draft {
+		if err := checkFinal(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, "pass -draft to write a spec for it anyway")
+			os.Exit(2)
+		}
+	}
+
+	s, err := newSpec(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var b strings.Builder
+	if *format == "json" {
+		err = writeSpecJSON(&b, s)
+	} else {
+		writeSpecMarkdown(&b, s)
+	}
+	if err == nil {
+		err = writeOutput(*out, []byte(b.String()))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
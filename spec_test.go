@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+func TestSpec(t *testing.T) {
+	v1, err := newSpec("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !v1.sortsLikeInput() || v1.Status != "final" {
+		t.Fatalf("v1 is final and sorts, got %+v", v1.SortInvariants)
+	}
+	var md strings.Builder
+	writeSpecMarkdown(&md, v1)
+	for _, want := range []string{
+		"| padding41 | 🏍 | 1f3cd |",
+		"| 1023 | 1111111111 |",
+		"emoji 3 = (byte 3 & 0x03) << 8 | byte 4",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Fatalf("markdown spec should have %q", want)
+		}
+	}
+
+	// the json has to be enough to rebuild the alphabet
+	v2, err := newSpec("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v2.sortsLikeInput() || v2.Status != "unregistered" {
+		t.Fatalf("v2 is unregistered and doesn't sort, got %s %+v", v2.Status, v2.SortInvariants)
+	}
+	var buf strings.Builder
+	if err := writeSpecJSON(&buf, v2); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if strings.Contains(buf.String(), `\u003c`) || !strings.Contains(buf.String(), "<<") {
+		t.Fatalf("json spec shouldn't escape the bit layout")
+	}
+	var parsed spec
+	if err := json.Unmarshal([]byte(buf.String()), &parsed); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var a ecoji.SymbolAlphabet
+	for _, e := range parsed.Emojis {
+		a.Emojis[e.Index] = e.Symbol
+	}
+	for i, p := range parsed.Padding {
+		a.Padding[i] = p.Symbol
+	}
+	enc, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if a != enc.Symbols() {
+		t.Fatalf("the json spec should describe v2")
+	}
+	if parsed.Checksum != v2.Checksum || len(parsed.SortInvariants) != len(v2.SortInvariants) {
+		t.Fatalf("json spec lost details")
+	}
+}
+
+// TestSpecDefault runs spec with no alphabet, it should write the newest
+// final one that replaces v1
+func TestSpecDefault(t *testing.T) {
+	withFinalV2(t)
+	out := t.TempDir() + "/spec.md"
+	runSpec([]string{"-o", out})
+	buf, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !strings.HasPrefix(string(buf), "# Ecoji alphabet v2-final\n") || !strings.Contains(string(buf), "| status | final |") {
+		t.Fatalf("spec should default to v2-final, got %.60q", buf)
+	}
+}
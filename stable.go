@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// stableSelector keeps the replacement a baseline alphabet had in each slot
+// as long as it's still a candidate, and hands the rest of the slots to
+// next. Adding one emoji to an exclusion then only moves the slot it was
+// in, instead of shifting every replacement after it.
+type stableSelector struct {
+	baseline ecoji.SymbolAlphabet
+	next     Selector
+}
+
+func (s stableSelector) Select(p plan, open []*slot, pool []rune) {
+	previous := make(map[*slot]string)
+	for i := range p.Padding {
+		previous[&p.Padding[i]] = s.baseline.Padding[i]
+	}
+	for i := range p.Emojis {
+		previous[&p.Emojis[i]] = s.baseline.Emojis[i]
+	}
+	candidate := make(map[rune]bool)
+	for _, r := range pool {
+		candidate[r] = true
+	}
+
+	used := make(map[rune]bool)
+	var rest []*slot
+	for _, sl := range open {
+		prev := []rune(previous[sl])
+		if len(prev) == 1 && candidate[prev[0]] && !used[prev[0]] {
+			sl.Replacement = prev[0]
+			used[prev[0]] = true
+			continue
+		}
+		rest = append(rest, sl)
+	}
+	var remaining []rune
+	for _, r := range pool {
+		if !used[r] {
+			remaining = append(remaining, r)
+		}
+	}
+	s.next.Select(p, rest, remaining)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStableSelector(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	ov := builtinOverrides()
+	baseline, err := buildPlan(ecojiset, ov, sequentialSelector{})
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, v2, err := planEncodings(baseline)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	// exclude the replacement of the first open slot, like adding it to
+	// redundantRunes
+	var dropped rune
+	var droppedIndex int
+	for _, s := range baseline.Emojis {
+		if _, pinned := ov.Emojis[s.Index]; s.replaced() && !pinned {
+			dropped, droppedIndex = s.Replacement, s.Index
+			break
+		}
+	}
+	opts := defaultPlanOptions()
+	opts.Exclusions = append(append([]exclusion{}, exclusions...), exclusion{Name: "dropped", Priority: 1, Runes: [][]rune{{dropped}}})
+
+	changed := func(sel Selector) []slotChange {
+		p, err := buildPlanWith(ecojiset, ov, sel, opts)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if validation := p.validation(); !validation.ok() {
+			t.Fatalf("plan has problems: %v", validation.errors())
+		}
+		a, err := newSymbolAlphabet(p.finalSymbols(), p.finalPaddingSymbols())
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		return diffAlphabets(v2.Symbols(), a)
+	}
+
+	// the stack shifts every replacement after the dropped one
+	if n := len(changed(sequentialSelector{})); n < 100 {
+		t.Fatalf("sequential should shift most replacements, only %d changed", n)
+	}
+	stable := changed(stableSelector{baseline: v2.Symbols(), next: sequentialSelector{}})
+	if len(stable) != 1 || stable[0].Index != droppedIndex || stable[0].From[0] != dropped {
+		t.Fatalf("stable should only change emoji %d, got %v", droppedIndex, stable)
+	}
+	if stable[0].To[0] == dropped {
+		t.Fatalf("the dropped emoji shouldn't come back")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/robindiddams/ecojifixer/ecoji"
+)
+
+// alphabetStats are the numbers we compare alphabets by, over the emojis and
+// the padding
+type alphabetStats struct {
+	Name            string
+	Symbols         int
+	ByGroup         map[string]int
+	ByVersion       map[string]int
+	Clusters        [][][]rune
+	ConfusablePairs int
+	// BytesPerSymbol is the average UTF-8 length of an emoji, every emoji
+	// is as likely as any other in encoded random data
+	BytesPerSymbol float64
+	// BytesPerInputByte is how much encoding grows the input, 5 bytes is 4
+	// emojis
+	BytesPerInputByte float64
+	// the same in UTF-16, which is what a lot of size limits count
+	UTF16PerSymbol    float64
+	UTF16PerInputByte float64
+	// IDSizes is the expected UTF-8 and UTF-16 bytes for the input sizes
+	// in idSizes, padding included
+	IDSizes map[int][2]float64
+}
+
+// idSizes are the input lengths we care about the most, UUIDs and SHA-1 and
+// SHA-256 digests
+var idSizes = []int{16, 20, 32}
+
+func utf8Size(s string) int { return len(s) }
+
+func utf16Size(s string) int { return len(utf16.Encode([]rune(s))) * 2 }
+
+// expectedSize is the average size of encoding n random bytes. Each group of
+// 5 is 4 emojis, any of them as likely. The last group only sets the top
+// bits of its last emoji, so that one is every 4th, 16th or 64th emoji, and
+// then the padding.
+func expectedSize(a ecoji.SymbolAlphabet, n int, size func(string) int) float64 {
+	mean := func(stride int) float64 {
+		var total int
+		for i := 0; i < len(a.Emojis); i += stride {
+			total += size(a.Emojis[i])
+		}
+		return float64(total) / float64(len(a.Emojis)/stride)
+	}
+	pad := func(i int) float64 { return float64(size(a.Padding[i])) }
+	all := mean(1)
+	total := float64(n/5*4) * all
+	switch n % 5 {
+	case 1:
+		total += mean(4) + 3*pad(0)
+	case 2:
+		total += all + mean(16) + 2*pad(0)
+	case 3:
+		total += 2*all + mean(64) + pad(0)
+	case 4:
+		total += 3*all + (pad(1)+pad(2)+pad(3)+pad(4))/4
+	}
+	return total
+}
+
+func analyzeAlphabet(name string, a ecoji.SymbolAlphabet) alphabetStats {
+	st := alphabetStats{
+		Name:      name,
+		ByGroup:   make(map[string]int),
+		ByVersion: make(map[string]int),
+	}
+	var all [][]rune
+	for _, s := range a.Padding {
+		all = append(all, []rune(s))
+	}
+	for _, s := range a.Emojis {
+		all = append(all, []rune(s))
+	}
+	for _, sym := range all {
+		st.Symbols++
+		info, ok := lookupSequence(sym)
+		if !ok && len(sym) == 1 {
+			info, ok = lookupEmoji(sym[0])
+		}
+		if !ok {
+			st.ByGroup["unknown"]++
+			st.ByVersion["unknown"]++
+			continue
+		}
+		st.ByGroup[info.Group]++
+		st.ByVersion[info.Version]++
+	}
+	st.Clusters = confusableClusters(all)
+	for _, c := range st.Clusters {
+		st.ConfusablePairs += len(c) * (len(c) - 1) / 2
+	}
+	st.BytesPerSymbol = expectedSize(a, 5, utf8Size) / 4
+	st.BytesPerInputByte = expectedSize(a, 5, utf8Size) / 5
+	st.UTF16PerSymbol = expectedSize(a, 5, utf16Size) / 4
+	st.UTF16PerInputByte = expectedSize(a, 5, utf16Size) / 5
+	st.IDSizes = make(map[int][2]float64)
+	for _, n := range idSizes {
+		st.IDSizes[n] = [2]float64{expectedSize(a, n, utf8Size), expectedSize(a, n, utf16Size)}
+	}
+	return st
+}
+
+// versionOrder sorts emoji versions like E0.6 before E13.0, unknown last
+func versionOrder(versions []string) {
+	num := func(v string) float64 {
+		f, err := strconv.ParseFloat(strings.TrimPrefix(v, "E"), 64)
+		if err != nil {
+			return 1e9
+		}
+		return f
+	}
+	sort.Slice(versions, func(i, j int) bool { return num(versions[i]) < num(versions[j]) })
+}
+
+// writeStats writes one table with a column per alphabet, so they're easy to
+// compare, then the biggest confusable clusters of each
+func writeStats(w io.Writer, stats []alphabetStats, clusters int) {
+	groups := make(map[string]bool)
+	versions := make(map[string]bool)
+	for _, st := range stats {
+		for g := range st.ByGroup {
+			groups[g] = true
+		}
+		for v := range st.ByVersion {
+			versions[v] = true
+		}
+	}
+	var groupNames, versionNames []string
+	for g := range groups {
+		groupNames = append(groupNames, g)
+	}
+	sort.Strings(groupNames)
+	for v := range versions {
+		versionNames = append(versionNames, v)
+	}
+	versionOrder(versionNames)
+
+	row := func(label string, cell func(st alphabetStats) string) {
+		fmt.Fprintf(w, "| %s |", label)
+		for _, st := range stats {
+			fmt.Fprintf(w, " %s |", cell(st))
+		}
+		fmt.Fprintln(w)
+	}
+	share := func(n, total int) string {
+		return fmt.Sprintf("%d (%.1f%%)", n, float64(n)*100/float64(total))
+	}
+
+	fmt.Fprintf(w, "|  |")
+	for _, st := range stats {
+		fmt.Fprintf(w, " %s |", st.Name)
+	}
+	fmt.Fprintf(w, "\n|--|%s\n", strings.Repeat("--|", len(stats)))
+	row("symbols", func(st alphabetStats) string { return strconv.Itoa(st.Symbols) })
+	row("confusable pairs", func(st alphabetStats) string { return strconv.Itoa(st.ConfusablePairs) })
+	row("confusable clusters", func(st alphabetStats) string { return strconv.Itoa(len(st.Clusters)) })
+	row("utf-8 bytes per emoji", func(st alphabetStats) string { return fmt.Sprintf("%.3f", st.BytesPerSymbol) })
+	row("utf-8 bytes per input byte", func(st alphabetStats) string { return fmt.Sprintf("%.3f", st.BytesPerInputByte) })
+	row("utf-16 bytes per emoji", func(st alphabetStats) string { return fmt.Sprintf("%.3f", st.UTF16PerSymbol) })
+	row("utf-16 bytes per input byte", func(st alphabetStats) string { return fmt.Sprintf("%.3f", st.UTF16PerInputByte) })
+	for _, n := range idSizes {
+		row(fmt.Sprintf("utf-8 / utf-16 bytes for %d input bytes", n), func(st alphabetStats) string {
+			return fmt.Sprintf("%.2f / %.2f", st.IDSizes[n][0], st.IDSizes[n][1])
+		})
+	}
+	for _, g := range groupNames {
+		row("group: "+g, func(st alphabetStats) string { return share(st.ByGroup[g], st.Symbols) })
+	}
+	for _, v := range versionNames {
+		row("version: "+v, func(st alphabetStats) string { return share(st.ByVersion[v], st.Symbols) })
+	}
+
+	for _, st := range stats {
+		if clusters == 0 || len(st.Clusters) == 0 {
+			continue
+		}
+		sorted := append([][][]rune{}, st.Clusters...)
+		sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+		if len(sorted) > clusters {
+			sorted = sorted[:clusters]
+		}
+		fmt.Fprintf(w, "\n## Confusable in %s\n\n", st.Name)
+		fmt.Fprintf(w, "| size | emojis |\n|------|--------|\n")
+		for _, c := range sorted {
+			var syms []string
+			for _, sym := range c {
+				syms = append(syms, fmt.Sprintf("%s (%s)", string(sym), symbolHex(sym)))
+			}
+			fmt.Fprintf(w, "| %d | %s |\n", len(c), strings.Join(syms, " "))
+		}
+	}
+}
+
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	clusters := flags.Int("clusters", 10, "how many of the biggest confusable clusters to list per alphabet")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer stats [flags] [alphabet...]")
+		fmt.Fprintln(flags.Output(), "compares alphabets by group, emoji version, confusable emojis and encoded size, v1 and v2 by default")
+		fmt.Fprintln(flags.Output(), "an alphabet is v1, v2, a generated emojis file or markdown tables like suggested.md")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{"v1", "v2"}
+	}
+	var stats []alphabetStats
+	for _, name := range names {
+		enc, err := loadEncoding(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		stats = append(stats, analyzeAlphabet(name, enc.Symbols()))
+	}
+	writeStats(os.Stdout, stats, *clusters)
+}
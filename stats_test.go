@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownAlphabet(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	opts := defaultPlanOptions()
+	opts.Sequences = true
+	p, err := buildPlanWith(ecojiset, builtinOverrides(), sequentialSelector{}, opts)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	var b strings.Builder
+	writeMarkdown(&b, p, func(rune) string { return "name (with parens 1f600)" })
+	path := filepath.Join(t.TempDir(), "plan.md")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	enc, err := loadEncoding(path)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	_, v2, err := planEncodings(p)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if enc.Symbols() != v2.Symbols() {
+		t.Fatalf("the markdown tables should read back as the plan")
+	}
+}
+
+func TestAlphabetStats(t *testing.T) {
+	var stats []alphabetStats
+	for _, name := range []string{"v1", "v2"} {
+		enc, err := loadEncoding(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		stats = append(stats, analyzeAlphabet(name, enc.Symbols()))
+	}
+	v1, v2 := stats[0], stats[1]
+	if v2.ConfusablePairs >= v1.ConfusablePairs {
+		t.Fatalf("v2 should have fewer confusable pairs than v1, got %d and %d", v2.ConfusablePairs, v1.ConfusablePairs)
+	}
+	if v1.ByGroup["unknown"] == 0 || v2.ByGroup["unknown"] != 0 {
+		t.Fatalf("only v1 should have runes that aren't emojis, got %d and %d", v1.ByGroup["unknown"], v2.ByGroup["unknown"])
+	}
+	if v1.BytesPerSymbol != 4 || v1.BytesPerInputByte != 3.2 {
+		t.Fatalf("every v1 emoji is 4 bytes, got %v", v1.BytesPerSymbol)
+	}
+	var b strings.Builder
+	writeStats(&b, stats, 1)
+	pairs := fmt.Sprintf("| confusable pairs | %d | %d |", v1.ConfusablePairs, v2.ConfusablePairs)
+	if !strings.Contains(b.String(), pairs) || !strings.Contains(b.String(), "## Confusable in v2") {
+		t.Fatalf("unexpected stats\n%s", b.String())
+	}
+}
+
+func TestExpectedSize(t *testing.T) {
+	v1, err := loadEncoding("v1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	// every v1 emoji is 4 bytes and the padding is 2615, 3 bytes
+	if got := expectedSize(v1.Symbols(), 1, utf8Size); got != 13 {
+		t.Fatalf("1 byte of v1 should be 13 bytes, got %v", got)
+	}
+
+	// every input of 1 and 2 bytes, the average has to come out exactly
+	v2, err := loadEncoding("v2")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for n, count := range []int{1: 1 << 8, 2: 1 << 16} {
+		if count == 0 {
+			continue
+		}
+		var total8, total16 int
+		buf := make([]byte, n)
+		for i := 0; i < count; i++ {
+			buf[0], buf[n-1] = byte(i>>8), byte(i)
+			encoded := v2.Encode(buf)
+			total8 += utf8Size(encoded)
+			total16 += utf16Size(encoded)
+		}
+		got8, got16 := float64(total8)/float64(count), float64(total16)/float64(count)
+		if want := expectedSize(v2.Symbols(), n, utf8Size); math.Abs(got8-want) > 1e-9 {
+			t.Fatalf("%d bytes should average %v utf-8 bytes, got %v", n, want, got8)
+		}
+		if want := expectedSize(v2.Symbols(), n, utf16Size); math.Abs(got16-want) > 1e-9 {
+			t.Fatalf("%d bytes should average %v utf-16 bytes, got %v", n, want, got16)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+type severity string
+
+const (
+	severityError   severity = "error"
+	severityWarning severity = "warning"
+)
+
+// finding is one broken rule. Index is -1 when it's about the whole
+// alphabet rather than one slot.
+type finding struct {
+	Rule     string   `json:"rule"`
+	Severity severity `json:"severity"`
+	Kind     string   `json:"kind,omitempty"`
+	Index    int      `json:"index"`
+	Message  string   `json:"message"`
+}
+
+type validationReport struct {
+	Findings []finding `json:"findings"`
+}
+
+func (r *validationReport) add(rule string, sev severity, kind string, index int, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, finding{
+		Rule:     rule,
+		Severity: sev,
+		Kind:     kind,
+		Index:    index,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ok is true when nothing broke a hard rule
+func (r validationReport) ok() bool {
+	return len(r.errors()) == 0
+}
+
+func (r validationReport) errors() []finding {
+	var errs []finding
+	for _, f := range r.Findings {
+		if f.Severity == severityError {
+			errs = append(errs, f)
+		}
+	}
+	return errs
+}
+
+// err sums up the errors, or is nil when there aren't any
+func (r validationReport) err() error {
+	errs := r.errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	msg := errs[0].Message
+	if errs[0].Index >= 0 {
+		msg = fmt.Sprintf("%s %d: %s", errs[0].Kind, errs[0].Index, msg)
+	}
+	if len(errs) > 1 {
+		msg = fmt.Sprintf("%s (and %d more)", msg, len(errs)-1)
+	}
+	return fmt.Errorf("%s: %s", errs[0].Rule, msg)
+}
+
+func (r validationReport) writeText(w io.Writer) {
+	for _, f := range r.Findings {
+		where := ""
+		if f.Index >= 0 {
+			where = fmt.Sprintf("%s %d: ", f.Kind, f.Index)
+		}
+		fmt.Fprintf(w, "%-7s %-17s %s%s\n", f.Severity, f.Rule, where, f.Message)
+	}
+	fmt.Fprintf(w, "%d errors, %d warnings\n", len(r.errors()), len(r.Findings)-len(r.errors()))
+}
+
+// validateAlphabet checks a v2 alphabet. v1 is the original set, if we have
+// it, so we can tell when a v1 emoji moved to a different index.
+//
+// Errors: the wrong number of runes, duplicates, padding that's also an
+// emoji, runes that aren't single code point emojis and v1 emojis that moved.
+// Warnings: anything out of sort order and emojis we meant to exclude.
+func validateAlphabet(emojis, padding, v1 []rune) validationReport {
+	return validateSymbols(symbolsOf(emojis), symbolsOf(padding), v1)
+}
+
+// checkSymbol reports whether sym is a single code point emoji, or a
+// fully-qualified emoji sequence
+func checkSymbol(sym []rune) bool {
+	if len(sym) == 1 {
+		return checkRune(sym[0])
+	}
+	info, ok := lookupSequence(sym)
+	return ok && info.Status == "fully-qualified"
+}
+
+// validateSymbols is validateAlphabet for alphabets with sequences. A v1
+// rune kept as a sequence at its own index is fine, anywhere else it moved.
+func validateSymbols(emojis, padding [][]rune, v1 []rune) validationReport {
+	var r validationReport
+
+	if len(emojis) != 1024 {
+		r.add("count", severityError, "", -1, "need 1024 emojis, got %d", len(emojis))
+	}
+	if len(padding) != 5 {
+		r.add("count", severityError, "", -1, "need 5 padding runes, got %d", len(padding))
+	}
+
+	type place struct {
+		kind  string
+		index int
+	}
+	seen := make(map[string]place)
+	check := func(kind string, i int, c []rune) {
+		if prev, dup := seen[string(c)]; dup {
+			rule := "duplicate"
+			if prev.kind != kind {
+				rule = "padding-collision"
+			}
+			r.add(rule, severityError, kind, i, "%s (%s) is also %s %d", string(c), symbolHex(c), prev.kind, prev.index)
+		} else {
+			seen[string(c)] = place{kind, i}
+		}
+		switch {
+		case checkSymbol(c):
+		case len(c) == 1:
+			r.add("not-emoji", severityError, kind, i, "%c (%x) is not a single code point emoji", c[0], c[0])
+		default:
+			r.add("not-emoji", severityError, kind, i, "%s (%s) is not a fully-qualified emoji sequence", string(c), symbolHex(c))
+		}
+	}
+	for i, c := range padding {
+		check("padding", i, c)
+	}
+	for i, c := range emojis {
+		check("emoji", i, c)
+	}
+
+	if len(v1) > 0 {
+		v1Index := make(map[rune]int)
+		for i, c := range v1 {
+			v1Index[c] = i
+		}
+		for i, c := range emojis {
+			if j, ok := v1Index[c[0]]; ok && j != i {
+				r.add("reused-v1", severityError, "emoji", i, "%s (%s) was emoji %d in v1", string(c), symbolHex(c), j)
+			}
+		}
+	}
+
+	excluded := make(map[rune]string)
+	for _, e := range exclusions {
+		for _, emoji := range e.Runes {
+			excluded[emoji[0]] = e.Name
+		}
+	}
+	for i, c := range padding {
+		if name, ok := excluded[c[0]]; ok {
+			r.add("excluded", severityWarning, "padding", i, "%s (%s) is in the %s exclusions", string(c), symbolHex(c), name)
+		}
+	}
+	for i, c := range emojis {
+		if name, ok := excluded[c[0]]; ok {
+			r.add("excluded", severityWarning, "emoji", i, "%s (%s) is in the %s exclusions", string(c), symbolHex(c), name)
+		}
+	}
+
+	// sequences sort by their first code point
+	if len(emojis) == 1024 && len(padding) == 5 {
+		var p plan
+		for i, c := range padding {
+			p.Padding = append(p.Padding, slot{Index: i, Original: c[0]})
+		}
+		for i, c := range emojis {
+			p.Emojis = append(p.Emojis, slot{Index: i, Original: c[0]})
+		}
+		kind := func(s *slot) string {
+			for i := range p.Padding {
+				if s == &p.Padding[i] {
+					return "padding"
+				}
+			}
+			return "emoji"
+		}
+		order := sortedSlots(p)
+		for i := 1; i < len(order); i++ {
+			if order[i].Original <= order[i-1].Original {
+				r.add("sort-order", severityWarning, kind(order[i]), order[i].Index, "%c (%x) sorts before %c (%x)",
+					order[i].Original, order[i].Original, order[i-1].Original, order[i-1].Original)
+			}
+		}
+	}
+	return r
+}
+
+// validation checks the alphabet a plan ends up with
+func (p plan) validation() validationReport {
+	var v1 []rune
+	for _, s := range p.Emojis {
+		v1 = append(v1, s.Original)
+	}
+	r := validateSymbols(p.finalSymbols(), p.finalPaddingSymbols(), v1)
+	for _, relaxed := range p.Relaxed {
+		r.add("relaxed", severityWarning, "", -1, "%c (%x) was excluded as %s but let back in to fill the alphabet",
+			relaxed.Rune, relaxed.Rune, relaxed.Exclusion)
+	}
+	return r
+}
+
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	paddingPath := flags.String("padding", paddingFile, "padding file, 5 hex runes or sequences")
+	v1Path := flags.String("v1", "emojisv1.txt", "v1 alphabet to compare against, empty to skip")
+	asJSON := flags.Bool("json", false, "write the report as json")
+	samples := flags.Int("samples", 1000, "random encodings to check grapheme segmentation with")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ecojifixer validate [flags] [emojis.txt]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	emojisPath := "emojis.txt"
+	if flags.NArg() > 0 {
+		emojisPath = flags.Arg(0)
+	}
+	emojis, err := readSymbolFile(emojisPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	padding, err := readSymbolFile(*paddingPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var v1 []rune
+	if *v1Path != "" {
+		if v1, err = readRuneFile(*v1Path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	report := validateSymbols(emojis, padding, v1)
+	rendering := checkSymbolRendering(emojis, padding, *samples, 1)
+	report.Findings = append(report.Findings, rendering.Findings...)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.writeText(os.Stdout)
+	}
+	if !report.ok() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidateAlphabet(t *testing.T) {
+	emojis, err := readRuneFile("emojis.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	padding, err := readRuneFile(paddingFile)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	v1, err := readRuneFile("emojisv1.txt")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if report := validateAlphabet(emojis, padding, v1); !report.ok() {
+		t.Fatalf("emojis.txt should be valid, got %v", report.errors())
+	}
+
+	rules := func(report validationReport) map[string]bool {
+		found := make(map[string]bool)
+		for _, f := range report.errors() {
+			found[f.Rule] = true
+		}
+		return found
+	}
+
+	broken := append([]rune{}, emojis...)
+	broken[10] = broken[11]
+	broken[12] = padding[0]
+	broken[2] = v1[500]
+	found := rules(validateAlphabet(broken, padding, v1))
+	for _, rule := range []string{"duplicate", "padding-collision", "reused-v1"} {
+		if !found[rule] {
+			t.Fatalf("should break %s, got %v", rule, found)
+		}
+	}
+
+	if found := rules(validateAlphabet(emojis[:1000], padding, v1)); !found["count"] {
+		t.Fatalf("should break count, got %v", found)
+	}
+	if found := rules(validateAlphabet(v1, padding, nil)); !found["not-emoji"] {
+		t.Fatalf("v1 should have runes that aren't emojis anymore")
+	}
+}
+
+func TestValidateSequences(t *testing.T) {
+	ecojiset := testEcojiset(t)
+	opts := defaultPlanOptions()
+	opts.Sequences = true
+	p, err := buildPlanWith(ecojiset, builtinOverrides(), sequentialSelector{}, opts)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if report := p.validation(); !report.ok() {
+		t.Fatalf("sequence plan has problems: %v", report.errors())
+	}
+	if report := checkSymbolRendering(p.finalSymbols(), p.finalPaddingSymbols(), 200, 1); !report.ok() {
+		t.Fatalf("sequence plan doesn't render: %v", report.errors())
+	}
+	_, v2, err := planEncodings(p)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	roundTrips(t, "sequences", v2)
+
+	rules := func(report validationReport) map[string]bool {
+		found := make(map[string]bool)
+		for _, f := range report.errors() {
+			found[f.Rule] = true
+		}
+		return found
+	}
+
+	// a text presentation sequence isn't an emoji, and half a keycap isn't
+	// one cluster that renders wide
+	emojis := p.finalSymbols()
+	emojis[0] = []rune{0x2600, 0xFE0E}
+	emojis[1] = []rune{0x23, 0xFE0F}
+	found := rules(validateSymbols(emojis, p.finalPaddingSymbols(), nil))
+	if !found["not-emoji"] {
+		t.Fatalf("expected not-emoji, got %v", found)
+	}
+	found = rules(checkSymbolRendering(emojis, p.finalPaddingSymbols(), 0, 1))
+	if !found["text-presentation"] || !found["render-width"] {
+		t.Fatalf("expected text-presentation and render-width, got %v", found)
+	}
+}
@@ -0,0 +1,40 @@
+function renderSlot(slot, padding) {
+  const el = document.createElement('div');
+  el.className = 'slot';
+  if (padding) {
+    el.classList.add('padding');
+  }
+  if (slot.replaced) {
+    el.classList.add('replaced');
+  }
+  el.title = slot.v1Hex + ' → ' + slot.v2Hex;
+  el.innerHTML =
+    '<div class="index">' + slot.index + '</div>' +
+    '<div class="emojis">' + slot.v1 + ' ' + (slot.replaced ? slot.v2 : '') + '</div>' +
+    '<div class="hex">' + slot.v1Hex + (slot.replaced ? ' → ' + slot.v2Hex : '') + '</div>';
+  return el;
+}
+
+fetch('/api/grid')
+  .then((resp) => resp.json())
+  .then((grid) => {
+    const padding = document.getElementById('padding');
+    grid.padding.forEach((slot) => padding.appendChild(renderSlot(slot, true)));
+    const emojis = document.getElementById('emojis');
+    grid.emojis.forEach((slot) => emojis.appendChild(renderSlot(slot, false)));
+  });
+
+document.querySelectorAll('button[data-op]').forEach((button) => {
+  button.addEventListener('click', () => {
+    const alphabet = document.getElementById('alphabet').value;
+    const output = document.getElementById('output');
+    fetch('/api/codec?op=' + button.dataset.op + '&alphabet=' + alphabet, {
+      method: 'POST',
+      body: document.getElementById('input').value,
+    })
+      .then((resp) => resp.text())
+      .then((text) => {
+        output.textContent = text;
+      });
+  });
+});